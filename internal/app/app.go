@@ -9,25 +9,44 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
+	"time"
 	"todoai/internal/config"
 	"todoai/internal/gateway/ai"
 	"todoai/internal/handler"
 	"todoai/internal/repository"
 	"todoai/internal/server"
 	"todoai/internal/service"
+	"todoai/pkg/cache"
 	"todoai/pkg/jwt"
 	smpt "todoai/pkg/mail/smtp"
 
-	"github.com/google/generative-ai-go/genai"
 	"golang.org/x/time/rate"
 )
 
+const (
+	// cacheCleanupInterval is how often expired entries are deleted from a cache store that
+	// keeps them, in batches of cacheCleanupBatch.
+	cacheCleanupInterval = time.Minute
+	cacheCleanupBatch    = 1000
+)
+
+// expiringStore is a cache store that keeps expired entries until they are deleted.
+type expiringStore interface {
+	DeleteExpired(ctx context.Context, limit int) (int, error)
+}
+
 type App struct {
 	server   *server.HttpServer
-	aIclient *genai.Client
+	jobs     service.JobsService
+	aIclient ai.AI
 	db       *sql.DB
 	log      *slog.Logger
+
+	// stop ends the background tasks, which wg waits for.
+	stop context.CancelFunc
+	wg   sync.WaitGroup
 }
 
 func NewApp(cfg *config.Config, log *slog.Logger) (*App, error) {
@@ -49,27 +68,138 @@ func NewApp(cfg *config.Config, log *slog.Logger) (*App, error) {
 	}
 	jwt := jwt.NewJWT()
 
-	aiClient, err := ai.NewClient(ctx, cfg.AI.Key)
+	var store cache.Store
+	if cfg.Cache.Enabled {
+		if store, err = newCacheStore(cfg, db); err != nil {
+			return nil, fmt.Errorf("create cache: %w", err)
+		}
+	}
+	aiClient, err := newAI(ctx, cfg, store, log)
 	if err != nil {
 		return nil, fmt.Errorf("create ai client: %w", err)
 	}
 
-	ratelimiterAI := rate.NewLimiter(rate.Limit(1), 1)
-
-	ai := ai.NewAI(cfg.AI.Model, aiClient, ratelimiterAI, log)
 	tm := service.NewTransactionManager(db)
-	service := service.NewService(db, tm, log, cfg, sender, jwt, ai)
-	handler := handler.NewHandler(service, jwt)
+	service, err := service.NewService(db, tm, log, cfg, sender, jwt, aiClient)
+	if err != nil {
+		aiClient.Close()
+		return nil, fmt.Errorf("create service: %w", err)
+	}
+	if err := service.Jobs.Start(ctx); err != nil {
+		return nil, fmt.Errorf("start jobs: %w", err)
+	}
+
+	handler := handler.NewHandler(service, jwt, cfg)
 	router := handler.HandlerRegistrator()
 
 	server := server.NewServer(cfg, router)
 
-	return &App{
+	app := &App{
 		server:   server,
+		jobs:     service.Jobs,
 		db:       db,
 		aIclient: aiClient,
 		log:      log,
-	}, nil
+	}
+	var background context.Context
+	background, app.stop = context.WithCancel(context.Background())
+	if expiring, ok := store.(expiringStore); ok {
+		app.wg.Add(1)
+		go app.cleanCache(background, expiring, cfg.Database.Timeout)
+	}
+	return app, nil
+}
+
+// cleanCache deletes the expired entries of the store every cacheCleanupInterval until ctx is
+// done. Each batch is a statement of its own, so no cleanup holds many rows locked for long.
+func (a *App) cleanCache(ctx context.Context, store expiringStore, timeout time.Duration) {
+	const op = "app.cleanCache"
+	defer a.wg.Done()
+
+	ticker := time.NewTicker(cacheCleanupInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for {
+			dbCtx, cancel := context.WithTimeout(ctx, timeout)
+			deleted, err := store.DeleteExpired(dbCtx, cacheCleanupBatch)
+			cancel()
+			if err != nil {
+				if ctx.Err() == nil {
+					a.log.Error(op, "error", err)
+				}
+				break
+			}
+			if deleted < cacheCleanupBatch {
+				break
+			}
+		}
+	}
+}
+
+// newAI builds the primary AI backend followed by the configured fallbacks.
+// All of them share one rate limiter. With a cache store every backend gets its own cache,
+// so an answer of a fallback is never served as one of the primary model.
+func newAI(ctx context.Context, cfg *config.Config, store cache.Store, log *slog.Logger) (ai.AI, error) {
+	limiter := rate.NewLimiter(rate.Limit(cfg.AI.RateLimit), cfg.AI.Burst)
+
+	backendsCfg := append([]config.AIBackend{{
+		Provider: cfg.AI.Provider,
+		Model:    cfg.AI.Model,
+		BaseURL:  cfg.AI.BaseURL,
+	}}, cfg.AI.Fallback...)
+
+	var backends []ai.Backend
+	for _, b := range backendsCfg {
+		key := cfg.AI.Key
+		if b.KeyEnv != "" {
+			key = os.Getenv(b.KeyEnv)
+		}
+
+		backend, err := ai.New(ctx, ai.Config{
+			Provider: b.Provider,
+			Model:    b.Model,
+			Key:      key,
+			BaseURL:  b.BaseURL,
+			Timeout:  cfg.AI.Timeout,
+		}, limiter, log)
+		if err != nil {
+			for _, created := range backends {
+				created.AI.Close()
+			}
+			return nil, fmt.Errorf("%s/%s: %w", b.Provider, b.Model, err)
+		}
+		name := b.Provider + "/" + b.Model
+		if store != nil {
+			backend = ai.NewCached(backend, store, name, cfg.Cache.TTL, log)
+		}
+		backends = append(backends, ai.Backend{Name: name, AI: backend})
+	}
+
+	return ai.NewChain(backends, ai.RetryPolicy{
+		Attempts:  cfg.AI.Retry.Attempts,
+		BaseDelay: cfg.AI.Retry.BaseDelay,
+		MaxDelay:  cfg.AI.Retry.MaxDelay,
+	}, ai.BreakerPolicy{
+		Failures: cfg.AI.Breaker.Failures,
+		Cooldown: cfg.AI.Breaker.Cooldown,
+	}, log), nil
+}
+
+func newCacheStore(cfg *config.Config, db *sql.DB) (cache.Store, error) {
+	switch cfg.Cache.Store {
+	case "memory":
+		return cache.NewLRU(cfg.Cache.Size), nil
+	case "postgres":
+		return repository.NewCacheRepo(db), nil
+	default:
+		return nil, fmt.Errorf("unknown cache store %q", cfg.Cache.Store)
+	}
 }
 
 func (a *App) Run() error {
@@ -98,10 +228,17 @@ func (a *App) Run() error {
 func (a *App) Close(ctx context.Context) error {
 	var errs []error
 
+	a.stop()
+	a.wg.Wait()
+
 	if err := a.server.Stop(ctx); err != nil {
 		errs = append(errs, fmt.Errorf("http server close: %w", err))
 	}
 
+	if err := a.jobs.Stop(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("jobs stop: %w", err))
+	}
+
 	if err := a.aIclient.Close(); err != nil {
 		errs = append(errs, fmt.Errorf("ai client close: %w", err))
 	}
@@ -44,30 +44,95 @@ type Config struct {
 	}
 
 	AI struct {
-		Key   string `env:"AI_KEY"`
-		Model string `yaml:"model"`
+		Provider  string        `yaml:"provider"`
+		Key       string        `env:"AI_KEY"`
+		Model     string        `yaml:"model"`
+		BaseURL   string        `yaml:"base_url"`
+		Timeout   time.Duration `yaml:"timeout"`
+		RateLimit float64       `yaml:"rate_limit"`
+		Burst     int           `yaml:"burst"`
+		Fallback  []AIBackend   `yaml:"fallback"`
+
+		Retry struct {
+			Attempts  int           `yaml:"attempts"`
+			BaseDelay time.Duration `yaml:"base_delay"`
+			MaxDelay  time.Duration `yaml:"max_delay"`
+		} `yaml:"retry"`
+
+		Breaker struct {
+			Failures int           `yaml:"failures"`
+			Cooldown time.Duration `yaml:"cooldown"`
+		} `yaml:"breaker"`
+	}
+
+	// Cache keeps model answers so that a document summarized again does not call the model.
+	// Store is "memory" (an LRU of Size entries) or "postgres".
+	Cache struct {
+		Enabled bool          `yaml:"enabled"`
+		Store   string        `yaml:"store"`
+		Size    int           `yaml:"size"`
+		TTL     time.Duration `yaml:"ttl"`
 	}
 
 	Summarizer struct {
 		ChunkSize    int     `yaml:"chunk_size"`
+		ChunkOverlap int     `yaml:"chunk_overlap"`
 		MaxLen       int     `yaml:"max_len"`
 		CompressTemp float32 `yaml:"compress_temp"`
 		FormatTemp   float32 `yaml:"format_temp"`
+		Workers      int     `yaml:"workers"`
+		// MaxSentences is the length of a chunk summary when the request sets no length.
+		MaxSentences int `yaml:"max_sentences"`
+		// DefaultMode is used when an upload does not name a mode.
+		DefaultMode string `yaml:"default_mode"`
 	}
 
-	Instructions struct {
-		Book                    string `yaml:"book_instruction"`
-		Jurisprudence           string `yaml:"jurisprudence_instruction"`
-		Article                 string `yaml:"article_instruction"`
-		Doc                     string `yaml:"doc_instruction"`
-		Def                     string `yaml:"default_instruction"`
-		Formatter_book          string `yaml:"formatter_book_instruction"`
-		Formatter_jurisprudence string `yaml:"formatter_jurisprudence_instruction"`
-		Formatter_article       string `yaml:"formatter_article_instruction"`
-		Formatter_doc           string `yaml:"formatter_doc_instruction"`
-		Formatter_def           string `yaml:"formatter_def_instruction"`
+	Jobs struct {
+		Workers   int           `yaml:"workers"`
+		QueueSize int           `yaml:"queue_size"`
+		Timeout   time.Duration `yaml:"timeout"`
 	}
 
+	// Quotas limit the model tokens a user may spend; zero means unlimited.
+	Quotas struct {
+		DailyTokens   int `yaml:"daily_tokens"`
+		MonthlyTokens int `yaml:"monthly_tokens"`
+	}
+
+	// Anonymous is the tier for callers of the upload endpoints without an access token,
+	// used by the public demo page. Requests without a token are rejected when it is disabled.
+	Anonymous struct {
+		Enabled     bool    `yaml:"enabled"`
+		RateLimit   float64 `yaml:"rate_limit"`
+		Burst       int     `yaml:"burst"`
+		MaxChunks   int     `yaml:"max_chunks"`
+		DailyTokens int     `yaml:"daily_tokens"`
+	}
+
+	// Prompts are the partials shared by the mode prompts, which include them with
+	// {{template "name" .}}.
+	Prompts map[string]string `yaml:"prompts"`
+
+	// Formats are the layout instructions appended to the formatter prompt by format name.
+	Formats map[string]string `yaml:"formats"`
+
+	// Structured holds the prompts of output=json: Prompt turns the chunk summaries into a
+	// structured summary and Repair fixes an answer that does not match the schema.
+	Structured struct {
+		Prompt string `yaml:"prompt"`
+		Repair string `yaml:"repair"`
+	} `yaml:"structured"`
+
+	// Todo holds the prompts of todo list generation: Extract finds the action items of one chunk
+	// and Merge joins the lists of all chunks into one.
+	Todo struct {
+		Extract string `yaml:"extract"`
+		Merge   string `yaml:"merge"`
+	} `yaml:"todo"`
+
+	// Modes are the built-in summarization modes by name. Users add their own through /api/modes.
+	Modes map[string]Mode `yaml:"modes"`
+
 	Email struct {
 		SMTPHost        string        `env:"SMTP_HOST"`
 		SMTPPassword    string        `env:"SMTP_PASSWORD"`
@@ -77,6 +142,24 @@ type Config struct {
 	}
 }
 
+// AIBackend is a fallback model tried when the primary one keeps failing.
+// KeyEnv names the environment variable holding its API key; the primary key is used when empty.
+type AIBackend struct {
+	Provider string `yaml:"provider"`
+	Model    string `yaml:"model"`
+	BaseURL  string `yaml:"base_url"`
+	KeyEnv   string `yaml:"key_env"`
+}
+
+// Mode holds the prompts of a summarization mode, which are text/template templates over
+// prompt.Vars. Temperatures that are left out fall back to the Summarizer ones.
+type Mode struct {
+	Compressor   string   `yaml:"compressor"`
+	Formatter    string   `yaml:"formatter"`
+	CompressTemp *float32 `yaml:"compress_temp"`
+	FormatTemp   *float32 `yaml:"format_temp"`
+}
+
 func Load() (*Config, error) {
 	cfgPath, envPath := fetchPath()
 	if cfgPath == "" || envPath == "" {
@@ -108,6 +191,10 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("parse env: %w", err)
 	}
 
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config:\n%w", err)
+	}
+
 	return cfg, nil
 }
 
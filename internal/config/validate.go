@@ -0,0 +1,135 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"todoai/internal/models"
+	"todoai/pkg/prompt"
+)
+
+// MaxTemperature is the highest sampling temperature accepted in the config. Some providers
+// allow up to 2, but most models produce noise well before that.
+const MaxTemperature = 1.0
+
+// Validate reports every problem in the config at once.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Server.Port != "", "server.port is empty")
+
+	check(c.AI.Provider != "", "ai.provider is empty")
+	check(c.AI.Model != "", "ai.model is empty")
+	check(c.AI.RateLimit > 0, "ai.rate_limit must be positive")
+	check(c.AI.Burst > 0, "ai.burst must be positive")
+	check(c.AI.Timeout > 0, "ai.timeout must be positive")
+	for i, b := range c.AI.Fallback {
+		check(b.Provider != "", "ai.fallback[%d].provider is empty", i)
+		check(b.Model != "", "ai.fallback[%d].model is empty", i)
+	}
+	check(c.AI.Retry.Attempts >= 1, "ai.retry.attempts must be at least 1")
+	check(c.AI.Retry.BaseDelay >= 0, "ai.retry.base_delay must not be negative")
+	check(c.AI.Retry.MaxDelay >= c.AI.Retry.BaseDelay, "ai.retry.max_delay must not be less than base_delay")
+	check(c.AI.Breaker.Failures > 0, "ai.breaker.failures must be positive")
+	check(c.AI.Breaker.Cooldown > 0, "ai.breaker.cooldown must be positive")
+
+	if c.Cache.Enabled {
+		check(c.Cache.Store == "memory" || c.Cache.Store == "postgres", "cache.store must be memory or postgres, got %q", c.Cache.Store)
+		check(c.Cache.Store != "memory" || c.Cache.Size > 0, "cache.size must be positive")
+		check(c.Cache.TTL > 0, "cache.ttl must be positive")
+	}
+
+	s := c.Summarizer
+	check(s.ChunkSize > 0, "summarizer.chunk_size must be positive")
+	check(s.ChunkOverlap >= 0 && s.ChunkOverlap < s.ChunkSize, "summarizer.chunk_overlap must be between 0 and chunk_size")
+	check(s.MaxLen > 0, "summarizer.max_len must be positive")
+	check(s.Workers > 0, "summarizer.workers must be positive")
+	check(s.MaxSentences > 0, "summarizer.max_sentences must be positive")
+	checkTemp(check, "summarizer.compress_temp", s.CompressTemp)
+	checkTemp(check, "summarizer.format_temp", s.FormatTemp)
+
+	check(c.Jobs.Workers > 0, "jobs.workers must be positive")
+	check(c.Jobs.QueueSize > 0, "jobs.queue_size must be positive")
+	check(c.Jobs.Timeout > 0, "jobs.timeout must be positive")
+
+	check(c.Quotas.DailyTokens >= 0, "quotas.daily_tokens must not be negative")
+	check(c.Quotas.MonthlyTokens >= 0, "quotas.monthly_tokens must not be negative")
+
+	if a := c.Anonymous; a.Enabled {
+		check(a.RateLimit > 0, "anonymous.rate_limit must be positive")
+		check(a.Burst > 0, "anonymous.burst must be positive")
+		check(a.MaxChunks > 0, "anonymous.max_chunks must be positive")
+		// Zero would leave the public tier without a token limit.
+		check(a.DailyTokens > 0, "anonymous.daily_tokens must be positive")
+	}
+
+	names := make([]string, 0, len(c.Modes))
+	for name := range c.Modes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	prompts, err := prompt.NewSet(c.Prompts)
+	check(err == nil, "prompts: %v", err)
+	for _, format := range models.Formats {
+		text := c.Formats[format]
+		check(strings.TrimSpace(text) != "", "formats.%s is empty", format)
+		if prompts != nil {
+			_, err := prompts.Parse(format, text)
+			check(err == nil, "formats: %v", err)
+		}
+	}
+	structured := []struct{ name, text string }{
+		{"prompt", c.Structured.Prompt},
+		{"repair", c.Structured.Repair},
+	}
+	for _, p := range structured {
+		check(strings.TrimSpace(p.text) != "", "structured.%s is empty", p.name)
+		if prompts != nil {
+			_, err := prompts.Parse(p.name, p.text)
+			check(err == nil, "structured: %v", err)
+		}
+	}
+	todo := []struct{ name, text string }{
+		{"extract", c.Todo.Extract},
+		{"merge", c.Todo.Merge},
+	}
+	for _, p := range todo {
+		check(strings.TrimSpace(p.text) != "", "todo.%s is empty", p.name)
+		if prompts != nil {
+			_, err := prompts.Parse(p.name, p.text)
+			check(err == nil, "todo: %v", err)
+		}
+	}
+	for _, name := range names {
+		mode := c.Modes[name]
+		check(strings.TrimSpace(mode.Compressor) != "", "modes.%s.compressor is empty", name)
+		check(strings.TrimSpace(mode.Formatter) != "", "modes.%s.formatter is empty", name)
+		if prompts != nil {
+			_, err := prompts.Parse("compressor", mode.Compressor)
+			check(err == nil, "modes.%s: %v", name, err)
+			_, err = prompts.Parse("formatter", mode.Formatter)
+			check(err == nil, "modes.%s: %v", name, err)
+		}
+		if mode.CompressTemp != nil {
+			checkTemp(check, "modes."+name+".compress_temp", *mode.CompressTemp)
+		}
+		if mode.FormatTemp != nil {
+			checkTemp(check, "modes."+name+".format_temp", *mode.FormatTemp)
+		}
+	}
+
+	_, ok := c.Modes[s.DefaultMode]
+	check(ok, "summarizer.default_mode %q is not one of the modes", s.DefaultMode)
+
+	return errors.Join(errs...)
+}
+
+func checkTemp(check func(ok bool, format string, args ...any), name string, temp float32) {
+	check(temp >= 0 && temp <= MaxTemperature, "%s must be between 0 and %v, got %v", name, MaxTemperature, temp)
+}
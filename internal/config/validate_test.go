@@ -0,0 +1,136 @@
+package config
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func loadTestConfig(t *testing.T) *Config {
+	t.Helper()
+	data, err := os.ReadFile("../../configs/config.yaml")
+	require.NoError(t, err)
+
+	cfg := &Config{}
+	require.NoError(t, yaml.Unmarshal(data, cfg))
+	return cfg
+}
+
+func TestValidate_ShippedConfig(t *testing.T) {
+	require.NoError(t, loadTestConfig(t).Validate())
+}
+
+func TestValidate(t *testing.T) {
+	tooHot := float32(1.5)
+
+	testTable := []struct {
+		name        string
+		change      func(cfg *Config)
+		expectedErr []string
+	}{
+		{
+			name: "empty prompts",
+			change: func(cfg *Config) {
+				cfg.Modes["book"] = Mode{Compressor: " ", FormatTemp: &tooHot}
+			},
+			expectedErr: []string{
+				"modes.book.compressor is empty",
+				"modes.book.formatter is empty",
+				"modes.book.format_temp must be between 0 and 1, got 1.5",
+			},
+		},
+		{
+			name: "unknown default mode",
+			change: func(cfg *Config) {
+				cfg.Summarizer.DefaultMode = "missing"
+			},
+			expectedErr: []string{`summarizer.default_mode "missing" is not one of the modes`},
+		},
+		{
+			name: "jobs and cache",
+			change: func(cfg *Config) {
+				cfg.Jobs.Timeout = 0
+				cfg.Cache.Enabled = true
+				cfg.Cache.TTL = 0
+			},
+			expectedErr: []string{
+				"jobs.timeout must be positive",
+				"cache.ttl must be positive",
+			},
+		},
+		{
+			name: "retries and breaker",
+			change: func(cfg *Config) {
+				cfg.AI.Timeout = 0
+				cfg.AI.Fallback = []AIBackend{{Provider: "openai"}}
+				cfg.AI.Retry.Attempts = 0
+				cfg.AI.Retry.BaseDelay = time.Second
+				cfg.AI.Retry.MaxDelay = time.Millisecond
+				cfg.AI.Breaker.Failures = 0
+				cfg.AI.Breaker.Cooldown = 0
+			},
+			expectedErr: []string{
+				"ai.timeout must be positive",
+				"ai.fallback[0].model is empty",
+				"ai.retry.attempts must be at least 1",
+				"ai.retry.max_delay must not be less than base_delay",
+				"ai.breaker.failures must be positive",
+				"ai.breaker.cooldown must be positive",
+			},
+		},
+		{
+			name: "anonymous tier",
+			change: func(cfg *Config) {
+				cfg.Anonymous.Enabled = true
+				cfg.Anonymous.RateLimit = 0
+				cfg.Anonymous.Burst = 0
+				cfg.Anonymous.MaxChunks = 0
+				cfg.Anonymous.DailyTokens = 0
+			},
+			expectedErr: []string{
+				"anonymous.rate_limit must be positive",
+				"anonymous.burst must be positive",
+				"anonymous.max_chunks must be positive",
+				"anonymous.daily_tokens must be positive",
+			},
+		},
+		{
+			name: "every problem is reported",
+			change: func(cfg *Config) {
+				cfg.Summarizer.ChunkSize = 0
+				cfg.Summarizer.MaxLen = -1
+				cfg.Summarizer.FormatTemp = 2
+			},
+			expectedErr: []string{
+				"summarizer.chunk_size must be positive",
+				"summarizer.chunk_overlap must be between 0 and chunk_size",
+				"summarizer.max_len must be positive",
+				"summarizer.format_temp must be between 0 and 1, got 2",
+			},
+		},
+	}
+
+	for _, tt := range testTable {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := loadTestConfig(t)
+			tt.change(cfg)
+
+			err := cfg.Validate()
+			require.Error(t, err)
+			for _, expected := range tt.expectedErr {
+				require.Contains(t, err.Error(), expected)
+			}
+		})
+	}
+}
+
+func TestValidate_DisabledAnonymousTier(t *testing.T) {
+	cfg := loadTestConfig(t)
+	cfg.Anonymous.Enabled = false
+	cfg.Anonymous.RateLimit = 0
+	cfg.Anonymous.MaxChunks = 0
+	require.NoError(t, cfg.Validate())
+}
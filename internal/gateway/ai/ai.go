@@ -2,64 +2,85 @@ package ai
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log/slog"
+)
+
+var (
+	ErrNotSupported = errors.New("not supported by provider")
+	// ErrBlocked means the prompt or the response was blocked by the provider's safety filters.
+	ErrBlocked = errors.New("response blocked by safety filters")
+	// ErrTruncated means the response hit the output token limit. The partial Result is
+	// returned along with it.
+	ErrTruncated = errors.New("response truncated by output token limit")
+	// ErrEmptyResponse means the model finished without producing any text.
+	ErrEmptyResponse = errors.New("empty response from model")
+)
 
-	"github.com/google/generative-ai-go/genai"
-	"golang.org/x/time/rate"
+const (
+	FinishStop       = "stop"
+	FinishMaxTokens  = "max_tokens"
+	FinishSafety     = "safety"
+	FinishRecitation = "recitation"
+	FinishOther      = "other"
 )
 
 type AI interface {
-	Generate(ctx context.Context, prompt, instruction string, temp float32) (string, error)
+	Generate(ctx context.Context, prompt, instruction string, temp float32) (Result, error)
+	// GenerateStream works like Generate but passes every piece of text to onChunk as soon as
+	// the model produces it. The full result is returned once the stream ends.
+	GenerateStream(ctx context.Context, prompt, instruction string, temp float32, onChunk func(text string)) (Result, error)
+	// GenerateJSON asks the model for a JSON object matching schema and returns it in
+	// Result.Text. Models do not always honour the schema, so the caller validates the object.
+	GenerateJSON(ctx context.Context, prompt, instruction string, temp float32, schema *Schema) (Result, error)
+	// CountTokens returns the number of model tokens in text.
+	CountTokens(ctx context.Context, text string) (int, error)
+	Close() error
 }
 
-type ai struct {
-	model   string
-	client  *genai.Client
-	limiter *rate.Limiter
-	log     *slog.Logger
+// Result is a model response: the text of all parts of the first candidate, the model that
+// produced it, why the model stopped, the safety ratings of the response and the tokens spent on it.
+// Cached is set when the response came from a cache instead of the model.
+type Result struct {
+	Text          string
+	Model         string
+	FinishReason  string
+	SafetyRatings []SafetyRating
+	Usage         Usage
+	Cached        bool
 }
 
-func NewAI(model string, client *genai.Client, limiter *rate.Limiter, log *slog.Logger) *ai {
-	return &ai{
-		model:   model,
-		client:  client,
-		limiter: limiter,
-		log:     log,
-	}
+type SafetyRating struct {
+	Category    string `json:"category"`
+	Probability string `json:"probability"`
+	Blocked     bool   `json:"blocked"`
 }
 
-func (a *ai) Generate(ctx context.Context, prompt, instruction string, temp float32) (string, error) {
-	const op = "gateway.ai.Generate"
-	if err := a.limiter.Wait(ctx); err != nil {
-		a.log.Error(op, "error:", err)
-		return "", err
-	}
-
-	model := a.client.GenerativeModel(a.model)
-	model.SystemInstruction = &genai.Content{
-		Parts: []genai.Part{
-			genai.Text(instruction),
-		},
-	}
-	model.Temperature = &temp
+type Usage struct {
+	PromptTokens int `json:"prompt_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
 
-	response, err := model.GenerateContent(ctx, genai.Text(prompt))
-	if err != nil {
-		a.log.Error(op, "error:", err)
-		return "", err
+// check turns a finished result into ErrBlocked, ErrTruncated or ErrEmptyResponse
+// when the model did not complete normally.
+func (r Result) check() error {
+	switch {
+	case r.FinishReason == FinishSafety || r.FinishReason == FinishRecitation:
+		return fmt.Errorf("%w: finish reason %s", ErrBlocked, r.FinishReason)
+	case r.FinishReason == FinishMaxTokens:
+		return ErrTruncated
+	case r.Text == "":
+		return ErrEmptyResponse
 	}
+	return nil
+}
 
-	return printResponse(response), nil
+// APIError is returned when the provider answers with a non-successful HTTP status.
+type APIError struct {
+	StatusCode int
+	Message    string
 }
 
-func printResponse(resp *genai.GenerateContentResponse) string {
-	for _, cand := range resp.Candidates {
-		if cand.Content != nil {
-			for _, part := range cand.Content.Parts {
-				return fmt.Sprint(part)
-			}
-		}
-	}
-	return ""
+func (e *APIError) Error() string {
+	return fmt.Sprintf("ai provider returned %d: %s", e.StatusCode, e.Message)
 }
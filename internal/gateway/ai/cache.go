@@ -0,0 +1,190 @@
+package ai
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"strconv"
+	"time"
+	"todoai/pkg/cache"
+)
+
+// cached answers repeated requests from a cache.Store. Every model call is cached on its own,
+// so a document summarized again hits the cache for every chunk, and an edited document
+// still reuses the summaries of the chunks that did not change.
+type cached struct {
+	ai    AI
+	store cache.Store
+	model string
+	ttl   time.Duration
+	log   *slog.Logger
+}
+
+// NewCached wraps ai with a cache. The model name is part of the cache key, so switching
+// models does not serve answers of the previous one. Wrap every backend of a chain on its own,
+// so that an answer is keyed by the model that actually produced it.
+func NewCached(ai AI, store cache.Store, model string, ttl time.Duration, log *slog.Logger) *cached {
+	return &cached{
+		ai:    ai,
+		store: store,
+		model: model,
+		ttl:   ttl,
+		log:   log,
+	}
+}
+
+// cachedResult is what is kept of a Result. Usage is left out: a cache hit costs nothing.
+type cachedResult struct {
+	Text          string         `json:"text"`
+	Model         string         `json:"model"`
+	FinishReason  string         `json:"finish_reason"`
+	SafetyRatings []SafetyRating `json:"safety_ratings,omitempty"`
+}
+
+func (c *cached) Generate(ctx context.Context, prompt, instruction string, temp float32) (Result, error) {
+	key := c.key("generate", instruction, strconv.FormatFloat(float64(temp), 'g', -1, 32), prompt)
+	if result, ok := c.get(ctx, key); ok {
+		return result, nil
+	}
+
+	result, err := c.ai.Generate(ctx, prompt, instruction, temp)
+	if err != nil {
+		return result, err
+	}
+	c.set(ctx, key, result)
+	return result, nil
+}
+
+// GenerateStream passes a cached answer to onChunk in one piece.
+func (c *cached) GenerateStream(ctx context.Context, prompt, instruction string, temp float32, onChunk func(text string)) (Result, error) {
+	key := c.key("generate", instruction, strconv.FormatFloat(float64(temp), 'g', -1, 32), prompt)
+	if result, ok := c.get(ctx, key); ok {
+		onChunk(result.Text)
+		return result, nil
+	}
+
+	result, err := c.ai.GenerateStream(ctx, prompt, instruction, temp, onChunk)
+	if err != nil {
+		return result, err
+	}
+	c.set(ctx, key, result)
+	return result, nil
+}
+
+// GenerateJSON keys the answer by the schema too. Only answers accepted by schema.Validate
+// are cached, so an answer the caller rejects and repairs is not served again.
+func (c *cached) GenerateJSON(ctx context.Context, prompt, instruction string, temp float32, schema *Schema) (Result, error) {
+	const op = "gateway.ai.cached.GenerateJSON"
+	encoded, err := json.Marshal(schema)
+	if err != nil {
+		c.log.Warn(op, "error", err)
+		return c.ai.GenerateJSON(ctx, prompt, instruction, temp, schema)
+	}
+
+	key := c.key("json", instruction, strconv.FormatFloat(float64(temp), 'g', -1, 32), string(encoded), prompt)
+	if result, ok := c.get(ctx, key); ok {
+		return result, nil
+	}
+
+	result, err := c.ai.GenerateJSON(ctx, prompt, instruction, temp, schema)
+	if err != nil {
+		return result, err
+	}
+	if schema.Validate == nil {
+		return result, nil
+	}
+	if err := schema.Validate(result.Text); err != nil {
+		c.log.Debug("ai answer not cached", "error", err)
+		return result, nil
+	}
+	c.set(ctx, key, result)
+	return result, nil
+}
+
+func (c *cached) CountTokens(ctx context.Context, text string) (int, error) {
+	const op = "gateway.ai.cached.CountTokens"
+	key := c.key("tokens", text)
+
+	value, ok, err := c.store.Get(ctx, key)
+	if err != nil {
+		c.log.Warn(op, "error", err)
+	}
+	if ok {
+		if tokens, err := strconv.Atoi(string(value)); err == nil {
+			return tokens, nil
+		}
+	}
+
+	tokens, err := c.ai.CountTokens(ctx, text)
+	if err != nil {
+		return 0, err
+	}
+	if err := c.store.Set(ctx, key, []byte(strconv.Itoa(tokens)), c.ttl); err != nil {
+		c.log.Warn(op, "error", err)
+	}
+	return tokens, nil
+}
+
+func (c *cached) Close() error {
+	return c.ai.Close()
+}
+
+// key hashes the model and the parts of a request. Parts are separated by a zero byte,
+// which does not occur in text.
+func (c *cached) key(parts ...string) string {
+	h := sha256.New()
+	h.Write([]byte(c.model))
+	for _, part := range parts {
+		h.Write([]byte{0})
+		h.Write([]byte(part))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// get treats a failing store as a miss.
+func (c *cached) get(ctx context.Context, key string) (Result, bool) {
+	const op = "gateway.ai.cached.get"
+	value, ok, err := c.store.Get(ctx, key)
+	if err != nil {
+		c.log.Warn(op, "error", err)
+		return Result{}, false
+	}
+	if !ok {
+		c.log.Debug("ai cache miss", "key", key)
+		return Result{}, false
+	}
+
+	var stored cachedResult
+	if err := json.Unmarshal(value, &stored); err != nil {
+		c.log.Warn(op, "error", err)
+		return Result{}, false
+	}
+
+	c.log.Debug("ai cache hit", "key", key)
+	return Result{
+		Text:          stored.Text,
+		Model:         stored.Model,
+		FinishReason:  stored.FinishReason,
+		SafetyRatings: stored.SafetyRatings,
+		Cached:        true,
+	}, true
+}
+
+func (c *cached) set(ctx context.Context, key string, result Result) {
+	const op = "gateway.ai.cached.set"
+	value, err := json.Marshal(cachedResult{
+		Text:          result.Text,
+		Model:         result.Model,
+		FinishReason:  result.FinishReason,
+		SafetyRatings: result.SafetyRatings,
+	})
+	if err != nil {
+		c.log.Warn(op, "error", err)
+		return
+	}
+	if err := c.store.Set(ctx, key, value, c.ttl); err != nil {
+		c.log.Warn(op, "error", err)
+	}
+}
@@ -0,0 +1,110 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+	"todoai/pkg/cache"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCached_Generate(t *testing.T) {
+	backend := &fakeAI{text: "summary"}
+	c := NewCached(backend, cache.NewLRU(10), "test-model", time.Minute, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ctx := context.Background()
+
+	result, err := c.Generate(ctx, "prompt", "instruction", 0.5)
+	require.NoError(t, err)
+	require.False(t, result.Cached)
+
+	result, err = c.Generate(ctx, "prompt", "instruction", 0.5)
+	require.NoError(t, err)
+	require.True(t, result.Cached)
+	require.Equal(t, "summary", result.Text)
+	require.Equal(t, 1, backend.calls)
+
+	var streamed []string
+	result, err = c.GenerateStream(ctx, "prompt", "instruction", 0.5, func(text string) {
+		streamed = append(streamed, text)
+	})
+	require.NoError(t, err)
+	require.True(t, result.Cached)
+	require.Equal(t, []string{"summary"}, streamed)
+	require.Equal(t, 1, backend.calls)
+
+	// Any other temperature, instruction or prompt is a different request.
+	_, err = c.Generate(ctx, "prompt", "instruction", 0.7)
+	require.NoError(t, err)
+	_, err = c.Generate(ctx, "prompt", "other instruction", 0.5)
+	require.NoError(t, err)
+	_, err = c.Generate(ctx, "other prompt", "instruction", 0.5)
+	require.NoError(t, err)
+	require.Equal(t, 4, backend.calls)
+}
+
+func TestCached_DoesNotCacheErrors(t *testing.T) {
+	backend := &fakeAI{text: "summary", errs: []error{ErrBlocked}}
+	c := NewCached(backend, cache.NewLRU(10), "test-model", time.Minute, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ctx := context.Background()
+
+	_, err := c.Generate(ctx, "prompt", "instruction", 0.5)
+	require.ErrorIs(t, err, ErrBlocked)
+
+	result, err := c.Generate(ctx, "prompt", "instruction", 0.5)
+	require.NoError(t, err)
+	require.False(t, result.Cached)
+	require.Equal(t, 2, backend.calls)
+}
+
+func TestCached_GenerateJSON(t *testing.T) {
+	testTable := []struct {
+		name           string
+		validate       func(text string) error
+		expectedCached bool
+	}{
+		{name: "accepted answer", validate: func(text string) error { return nil }, expectedCached: true},
+		{name: "rejected answer", validate: func(text string) error { return errors.New("invalid") }, expectedCached: false},
+		{name: "no validator", expectedCached: false},
+	}
+
+	for _, tt := range testTable {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := &fakeAI{text: `{"title":"t"}`}
+			c := NewCached(backend, cache.NewLRU(10), "test-model", time.Minute, slog.New(slog.NewTextHandler(io.Discard, nil)))
+			schema := &Schema{Type: TypeObject, Validate: tt.validate}
+
+			_, err := c.GenerateJSON(context.Background(), "prompt", "instruction", 0.5, schema)
+			require.NoError(t, err)
+			result, err := c.GenerateJSON(context.Background(), "prompt", "instruction", 0.5, schema)
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedCached, result.Cached)
+		})
+	}
+}
+
+func TestCached_FallbackAnswerIsNotServedForPrimary(t *testing.T) {
+	store := cache.NewLRU(10)
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	unavailable := &APIError{StatusCode: http.StatusServiceUnavailable}
+	primary := &fakeAI{text: "primary", errs: []error{unavailable}}
+	secondary := &fakeAI{text: "secondary"}
+
+	c := NewChain([]Backend{
+		{Name: "primary", AI: NewCached(primary, store, "primary", time.Minute, log)},
+		{Name: "secondary", AI: NewCached(secondary, store, "secondary", time.Minute, log)},
+	}, RetryPolicy{Attempts: 1}, BreakerPolicy{Failures: 10, Cooldown: time.Minute}, log)
+
+	result, err := c.Generate(context.Background(), "prompt", "instruction", 0.5)
+	require.NoError(t, err)
+	require.Equal(t, "secondary", result.Text)
+
+	result, err = c.Generate(context.Background(), "prompt", "instruction", 0.5)
+	require.NoError(t, err)
+	require.Equal(t, "primary", result.Text)
+	require.False(t, result.Cached)
+}
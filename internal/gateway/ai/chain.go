@@ -0,0 +1,239 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+var ErrNoBackend = errors.New("no ai backend available")
+
+// Backend is one entry of a fallback chain.
+type Backend struct {
+	// Name identifies the backend in logs, e.g. "genai/gemini-2.0-flash-lite".
+	Name string
+	AI   AI
+}
+
+type RetryPolicy struct {
+	// Attempts is the number of calls made to a backend before falling back to the next one.
+	Attempts  int
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+type BreakerPolicy struct {
+	// Failures is the number of consecutive failures that opens the breaker of a backend.
+	Failures int
+	// Cooldown is how long an open breaker rejects calls before letting a trial call through.
+	Cooldown time.Duration
+}
+
+type chainBackend struct {
+	Backend
+	breaker *breaker
+}
+
+// chain tries its backends in priority order. Retryable errors are retried with exponential
+// backoff and jitter; a backend whose breaker is open is skipped.
+type chain struct {
+	backends []chainBackend
+	retry    RetryPolicy
+	log      *slog.Logger
+}
+
+func NewChain(backends []Backend, retry RetryPolicy, breaker BreakerPolicy, log *slog.Logger) *chain {
+	c := &chain{
+		retry: retry,
+		log:   log,
+	}
+	for _, b := range backends {
+		c.backends = append(c.backends, chainBackend{
+			Backend: b,
+			breaker: newBreaker(breaker.Failures, breaker.Cooldown),
+		})
+	}
+	return c
+}
+
+func (c *chain) Generate(ctx context.Context, prompt, instruction string, temp float32) (Result, error) {
+	var result Result
+	err := c.call(ctx, "Generate", func(ai AI) error {
+		var err error
+		result, err = ai.Generate(ctx, prompt, instruction, temp)
+		return err
+	})
+	return result, err
+}
+
+func (c *chain) GenerateJSON(ctx context.Context, prompt, instruction string, temp float32, schema *Schema) (Result, error) {
+	var result Result
+	err := c.call(ctx, "GenerateJSON", func(ai AI) error {
+		var err error
+		result, err = ai.GenerateJSON(ctx, prompt, instruction, temp, schema)
+		return err
+	})
+	return result, err
+}
+
+// GenerateStream retries and falls back only until the first piece of text has been passed
+// to onChunk; after that a failure is returned to the caller.
+func (c *chain) GenerateStream(ctx context.Context, prompt, instruction string, temp float32, onChunk func(text string)) (Result, error) {
+	var result Result
+	streamed := false
+	err := c.call(ctx, "GenerateStream", func(ai AI) error {
+		var err error
+		result, err = ai.GenerateStream(ctx, prompt, instruction, temp, func(chunk string) {
+			streamed = true
+			onChunk(chunk)
+		})
+		if err != nil && streamed {
+			return &permanentError{err}
+		}
+		return err
+	})
+	return result, err
+}
+
+// CountTokens asks the backends in order and returns the first answer.
+func (c *chain) CountTokens(ctx context.Context, text string) (int, error) {
+	err := ErrNoBackend
+	for _, b := range c.backends {
+		var tokens int
+		tokens, err = b.AI.CountTokens(ctx, text)
+		if err == nil {
+			return tokens, nil
+		}
+	}
+	return 0, err
+}
+
+func (c *chain) Close() error {
+	var errs []error
+	for _, b := range c.backends {
+		if err := b.AI.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (c *chain) call(ctx context.Context, op string, fn func(ai AI) error) error {
+	const prefix = "gateway.ai.chain."
+	lastErr := ErrNoBackend
+
+	for i, b := range c.backends {
+		if !b.breaker.Allow() {
+			c.log.Warn(prefix+op, "message", "backend skipped, circuit open", "backend", b.Name)
+			continue
+		}
+		if i > 0 {
+			c.log.Warn(prefix+op, "message", "falling back to backend", "backend", b.Name, "error", lastErr)
+		}
+
+		for attempt := 1; ; attempt++ {
+			err := fn(b.AI)
+			if err == nil {
+				b.breaker.Success()
+				return nil
+			}
+
+			var permanent *permanentError
+			if errors.As(err, &permanent) {
+				b.breaker.Abort()
+				return permanent.err
+			}
+			if ctx.Err() != nil {
+				b.breaker.Abort()
+				return err
+			}
+			if !IsRetryable(err) {
+				// The backend answered, so it is healthy; the request itself is at fault.
+				b.breaker.Success()
+				return err
+			}
+
+			lastErr = err
+			if b.breaker.Failure() {
+				c.log.Warn(prefix+op, "message", "circuit opened", "backend", b.Name, "error", err)
+				break
+			}
+			if attempt >= c.retry.Attempts {
+				break
+			}
+
+			delay := c.backoff(attempt)
+			c.log.Warn(prefix+op, "message", "retrying", "backend", b.Name, "attempt", attempt, "delay", delay, "error", err)
+			if err := sleep(ctx, delay); err != nil {
+				return err
+			}
+		}
+	}
+
+	c.log.Error(prefix+op, "message", "all backends failed", "error", lastErr)
+	return lastErr
+}
+
+// backoff returns the delay before the next attempt: BaseDelay doubled for every attempt,
+// capped at MaxDelay, with the upper half randomized.
+func (c *chain) backoff(attempt int) time.Duration {
+	delay := c.retry.BaseDelay << (attempt - 1)
+	if delay <= 0 || (c.retry.MaxDelay > 0 && delay > c.retry.MaxDelay) {
+		delay = c.retry.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return delay/2 + rand.N(delay/2+1)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// permanentError stops the chain from retrying or falling back.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// IsRetryable reports whether err is a transient failure worth retrying or falling back on:
+// rate limiting, server errors, empty responses and any failure to reach the backend, such as
+// a refused or reset connection, a DNS failure or a timeout. Errors of the request itself, like
+// other 4xx answers and blocked or truncated responses, are not retryable.
+func IsRetryable(err error) bool {
+	if errors.Is(err, ErrEmptyResponse) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= http.StatusInternalServerError
+	}
+
+	// Every failed request comes as a *url.Error, which also covers permanent mistakes like an
+	// unsupported scheme, so only the network errors it wraps are looked at.
+	var opErr *net.OpError
+	var dnsErr *net.DNSError
+	if errors.As(err, &opErr) || errors.As(err, &dnsErr) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
@@ -0,0 +1,233 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+// fakeAI answers Generate with the queued errors first and then with text.
+type fakeAI struct {
+	text  string
+	errs  []error
+	calls int
+}
+
+func (f *fakeAI) Generate(ctx context.Context, prompt, instruction string, temp float32) (Result, error) {
+	f.calls++
+	if len(f.errs) > 0 {
+		err := f.errs[0]
+		f.errs = f.errs[1:]
+		return Result{}, err
+	}
+	return Result{Text: f.text, FinishReason: FinishStop}, nil
+}
+
+func (f *fakeAI) GenerateStream(ctx context.Context, prompt, instruction string, temp float32, onChunk func(text string)) (Result, error) {
+	result, err := f.Generate(ctx, prompt, instruction, temp)
+	if err == nil {
+		onChunk(result.Text)
+	}
+	return result, err
+}
+
+func (f *fakeAI) GenerateJSON(ctx context.Context, prompt, instruction string, temp float32, schema *Schema) (Result, error) {
+	return f.Generate(ctx, prompt, instruction, temp)
+}
+
+func (f *fakeAI) CountTokens(ctx context.Context, text string) (int, error) {
+	return 0, ErrNotSupported
+}
+
+func (f *fakeAI) Close() error { return nil }
+
+func TestChain_Generate(t *testing.T) {
+	unavailable := &APIError{StatusCode: http.StatusServiceUnavailable}
+	badRequest := &APIError{StatusCode: http.StatusBadRequest}
+
+	testTable := []struct {
+		name          string
+		primary       *fakeAI
+		secondary     *fakeAI
+		expectedText  string
+		expectedErr   error
+		primaryCalls  int
+		fallbackCalls int
+	}{
+		{
+			name:         "OK",
+			primary:      &fakeAI{text: "primary"},
+			secondary:    &fakeAI{text: "secondary"},
+			expectedText: "primary",
+			primaryCalls: 1,
+		},
+		{
+			name:         "retries retryable errors",
+			primary:      &fakeAI{text: "primary", errs: []error{unavailable, unavailable}},
+			secondary:    &fakeAI{text: "secondary"},
+			expectedText: "primary",
+			primaryCalls: 3,
+		},
+		{
+			name:          "falls back after the last attempt",
+			primary:       &fakeAI{text: "primary", errs: []error{unavailable, unavailable, unavailable}},
+			secondary:     &fakeAI{text: "secondary"},
+			expectedText:  "secondary",
+			primaryCalls:  3,
+			fallbackCalls: 1,
+		},
+		{
+			name:          "falls back on empty responses",
+			primary:       &fakeAI{text: "primary", errs: []error{ErrEmptyResponse, ErrEmptyResponse, ErrEmptyResponse}},
+			secondary:     &fakeAI{text: "secondary"},
+			expectedText:  "secondary",
+			primaryCalls:  3,
+			fallbackCalls: 1,
+		},
+		{
+			name:         "does not retry blocked responses",
+			primary:      &fakeAI{text: "primary", errs: []error{ErrBlocked}},
+			secondary:    &fakeAI{text: "secondary"},
+			expectedErr:  ErrBlocked,
+			primaryCalls: 1,
+		},
+		{
+			name:         "does not retry other errors",
+			primary:      &fakeAI{text: "primary", errs: []error{badRequest}},
+			secondary:    &fakeAI{text: "secondary"},
+			expectedErr:  badRequest,
+			primaryCalls: 1,
+		},
+		{
+			name:          "all backends fail",
+			primary:       &fakeAI{errs: []error{unavailable, unavailable, unavailable}},
+			secondary:     &fakeAI{errs: []error{unavailable, unavailable, unavailable}},
+			expectedErr:   unavailable,
+			primaryCalls:  3,
+			fallbackCalls: 3,
+		},
+	}
+
+	for _, tt := range testTable {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewChain([]Backend{
+				{Name: "primary", AI: tt.primary},
+				{Name: "secondary", AI: tt.secondary},
+			}, RetryPolicy{Attempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+				BreakerPolicy{Failures: 10, Cooldown: time.Minute},
+				slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+			result, err := c.Generate(context.Background(), "prompt", "instruction", 0.5)
+			if tt.expectedErr != nil {
+				require.True(t, errors.Is(err, tt.expectedErr), err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tt.expectedText, result.Text)
+			}
+			require.Equal(t, tt.primaryCalls, tt.primary.calls)
+			require.Equal(t, tt.fallbackCalls, tt.secondary.calls)
+		})
+	}
+}
+
+func TestChain_FallsBackWhenBackendIsDown(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	down := NewOpenAI("http://"+addr+"/v1", "secret", "test-model", &http.Client{}, time.Second, rate.NewLimiter(rate.Inf, 1), log)
+	secondary := &fakeAI{text: "secondary"}
+
+	c := NewChain([]Backend{
+		{Name: "down", AI: down},
+		{Name: "secondary", AI: secondary},
+	}, RetryPolicy{Attempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+		BreakerPolicy{Failures: 10, Cooldown: time.Minute}, log)
+
+	result, err := c.Generate(context.Background(), "prompt", "instruction", 0.5)
+	require.NoError(t, err)
+	require.Equal(t, "secondary", result.Text)
+	require.Equal(t, 1, secondary.calls)
+}
+
+func TestIsRetryable(t *testing.T) {
+	testTable := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{name: "rate limited", err: &APIError{StatusCode: http.StatusTooManyRequests}, expected: true},
+		{name: "server error", err: &APIError{StatusCode: http.StatusNotImplemented}, expected: true},
+		{name: "bad request", err: &APIError{StatusCode: http.StatusBadRequest}, expected: false},
+		{name: "empty response", err: ErrEmptyResponse, expected: true},
+		{name: "blocked", err: ErrBlocked, expected: false},
+		{name: "truncated", err: ErrTruncated, expected: false},
+		{name: "unexpected EOF", err: fmt.Errorf("read body: %w", io.ErrUnexpectedEOF), expected: true},
+		{name: "connection reset", err: &net.OpError{Op: "read", Err: syscall.ECONNRESET}, expected: true},
+		{name: "connection refused", err: &url.Error{Op: "Post", Err: &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}}, expected: true},
+		{name: "DNS failure", err: &net.DNSError{Err: "no such host", IsNotFound: true}, expected: true},
+		{name: "request timeout", err: &url.Error{Op: "Post", Err: context.DeadlineExceeded}, expected: true},
+		{name: "unsupported scheme", err: &url.Error{Op: "Post", URL: "ftp://api", Err: errors.New(`unsupported protocol scheme "ftp"`)}, expected: false},
+	}
+
+	for _, tt := range testTable {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, IsRetryable(tt.err))
+		})
+	}
+}
+
+func TestChain_BreakerSkipsBackend(t *testing.T) {
+	unavailable := &APIError{StatusCode: http.StatusServiceUnavailable}
+	primary := &fakeAI{text: "primary", errs: []error{unavailable, unavailable}}
+	secondary := &fakeAI{text: "secondary"}
+
+	c := NewChain([]Backend{
+		{Name: "primary", AI: primary},
+		{Name: "secondary", AI: secondary},
+	}, RetryPolicy{Attempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+		BreakerPolicy{Failures: 2, Cooldown: time.Minute},
+		slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	for i := 0; i < 2; i++ {
+		result, err := c.Generate(context.Background(), "prompt", "instruction", 0.5)
+		require.NoError(t, err)
+		require.Equal(t, "secondary", result.Text)
+	}
+	require.Equal(t, 2, primary.calls)
+	require.Equal(t, 2, secondary.calls)
+}
+
+func TestBreaker(t *testing.T) {
+	now := time.Now()
+	b := newBreaker(2, time.Minute)
+	b.now = func() time.Time { return now }
+
+	require.True(t, b.Allow())
+	require.False(t, b.Failure())
+	require.True(t, b.Failure())
+	require.Equal(t, breakerOpen, b.State())
+	require.False(t, b.Allow())
+
+	now = now.Add(time.Minute)
+	require.True(t, b.Allow())
+	require.Equal(t, breakerHalfOpen, b.State())
+	require.False(t, b.Allow())
+
+	b.Success()
+	require.Equal(t, breakerClosed, b.State())
+	require.True(t, b.Allow())
+}
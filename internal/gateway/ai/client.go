@@ -7,8 +7,13 @@ import (
 	"google.golang.org/api/option"
 )
 
-func NewClient(ctx context.Context, key string) (*genai.Client, error) {
-	client, err := genai.NewClient(ctx, option.WithAPIKey(key))
+func NewClient(ctx context.Context, key string, baseURL string) (*genai.Client, error) {
+	opts := []option.ClientOption{option.WithAPIKey(key)}
+	if baseURL != "" {
+		opts = append(opts, option.WithEndpoint(baseURL))
+	}
+
+	client, err := genai.NewClient(ctx, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,259 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/generative-ai-go/genai"
+	"github.com/googleapis/gax-go/v2/apierror"
+	"golang.org/x/time/rate"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/iterator"
+)
+
+const ProviderGenAI = "genai"
+
+func init() {
+	Register(ProviderGenAI, func(ctx context.Context, cfg Config, limiter *rate.Limiter, log *slog.Logger) (AI, error) {
+		client, err := NewClient(ctx, cfg.Key, cfg.BaseURL)
+		if err != nil {
+			return nil, fmt.Errorf("create genai client: %w", err)
+		}
+		return NewGenAI(cfg.Model, client, cfg.Timeout, limiter, log), nil
+	})
+}
+
+type genaiAI struct {
+	model  string
+	client *genai.Client
+	// timeout limits a whole call, and the silence between two responses of a stream.
+	// Zero means no limit.
+	timeout time.Duration
+	limiter *rate.Limiter
+	log     *slog.Logger
+}
+
+func NewGenAI(model string, client *genai.Client, timeout time.Duration, limiter *rate.Limiter, log *slog.Logger) *genaiAI {
+	return &genaiAI{
+		model:   model,
+		client:  client,
+		timeout: timeout,
+		limiter: limiter,
+		log:     log,
+	}
+}
+
+func (a *genaiAI) Generate(ctx context.Context, prompt, instruction string, temp float32) (Result, error) {
+	const op = "gateway.ai.genai.Generate"
+	if err := a.limiter.Wait(ctx); err != nil {
+		a.log.Error(op, "error:", err)
+		return Result{}, err
+	}
+
+	ctx, cancel := withTimeout(ctx, a.timeout)
+	defer cancel()
+	model := a.newModel(instruction, temp)
+	response, err := model.GenerateContent(ctx, genai.Text(prompt))
+	if err != nil {
+		a.log.Error(op, "error:", err)
+		return Result{}, wrapError(err)
+	}
+
+	result := newResult(response, responseText(response))
+	result.Model = a.model
+	if err := result.check(); err != nil {
+		a.log.Warn(op, "error:", err)
+		return result, err
+	}
+	return result, nil
+}
+
+func (a *genaiAI) GenerateStream(ctx context.Context, prompt, instruction string, temp float32, onChunk func(text string)) (Result, error) {
+	const op = "gateway.ai.genai.GenerateStream"
+	if err := a.limiter.Wait(ctx); err != nil {
+		a.log.Error(op, "error:", err)
+		return Result{}, err
+	}
+
+	// A stream may run for long, so only a stream that stays silent for the timeout is cut off.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	idle := startIdleTimer(a.timeout, cancel)
+	defer idle.stop()
+
+	model := a.newModel(instruction, temp)
+	iter := model.GenerateContentStream(ctx, genai.Text(prompt))
+
+	var text strings.Builder
+	for {
+		response, err := iter.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			err = idle.err(a.timeout, wrapError(err))
+			a.log.Error(op, "error:", err)
+			return Result{}, err
+		}
+		idle.reset(a.timeout)
+
+		chunk := responseText(response)
+		if chunk == "" {
+			continue
+		}
+		text.WriteString(chunk)
+		onChunk(chunk)
+	}
+
+	result := newResult(iter.MergedResponse(), text.String())
+	result.Model = a.model
+	if err := result.check(); err != nil {
+		a.log.Warn(op, "error:", err)
+		return result, err
+	}
+	return result, nil
+}
+
+func (a *genaiAI) GenerateJSON(ctx context.Context, prompt, instruction string, temp float32, schema *Schema) (Result, error) {
+	const op = "gateway.ai.genai.GenerateJSON"
+	if err := a.limiter.Wait(ctx); err != nil {
+		a.log.Error(op, "error:", err)
+		return Result{}, err
+	}
+
+	ctx, cancel := withTimeout(ctx, a.timeout)
+	defer cancel()
+	model := a.newModel(instruction, temp)
+	model.ResponseMIMEType = "application/json"
+	model.ResponseSchema = schema.genai()
+	response, err := model.GenerateContent(ctx, genai.Text(prompt))
+	if err != nil {
+		a.log.Error(op, "error:", err)
+		return Result{}, wrapError(err)
+	}
+
+	result := newResult(response, responseText(response))
+	result.Model = a.model
+	if err := result.check(); err != nil {
+		a.log.Warn(op, "error:", err)
+		return result, err
+	}
+	return result, nil
+}
+
+func (a *genaiAI) CountTokens(ctx context.Context, text string) (int, error) {
+	const op = "gateway.ai.genai.CountTokens"
+	if err := a.limiter.Wait(ctx); err != nil {
+		a.log.Error(op, "error:", err)
+		return 0, err
+	}
+
+	ctx, cancel := withTimeout(ctx, a.timeout)
+	defer cancel()
+	response, err := a.client.GenerativeModel(a.model).CountTokens(ctx, genai.Text(text))
+	if err != nil {
+		a.log.Error(op, "error:", err)
+		return 0, wrapError(err)
+	}
+
+	return int(response.TotalTokens), nil
+}
+
+func (a *genaiAI) Close() error {
+	return a.client.Close()
+}
+
+func (a *genaiAI) newModel(instruction string, temp float32) *genai.GenerativeModel {
+	model := a.client.GenerativeModel(a.model)
+	model.SystemInstruction = &genai.Content{
+		Parts: []genai.Part{
+			genai.Text(instruction),
+		},
+	}
+	model.Temperature = &temp
+	return model
+}
+
+// wrapError turns HTTP failures of the Google API into APIError so that callers can tell
+// transient errors apart, and safety blocks into ErrBlocked.
+func wrapError(err error) error {
+	var blockedErr *genai.BlockedError
+	if errors.As(err, &blockedErr) {
+		return fmt.Errorf("%w: %s", ErrBlocked, blockedErr.Error())
+	}
+	var apiErr *apierror.APIError
+	if errors.As(err, &apiErr) && apiErr.HTTPCode() > 0 {
+		return &APIError{StatusCode: apiErr.HTTPCode(), Message: apiErr.Error()}
+	}
+	var googleErr *googleapi.Error
+	if errors.As(err, &googleErr) {
+		return &APIError{StatusCode: googleErr.Code, Message: googleErr.Message}
+	}
+	return err
+}
+
+// newResult describes the first candidate of resp. The text is passed separately because a
+// streamed response is assembled by the caller.
+func newResult(resp *genai.GenerateContentResponse, text string) Result {
+	result := Result{Text: text}
+	if resp == nil {
+		return result
+	}
+
+	if resp.UsageMetadata != nil {
+		result.Usage = Usage{
+			PromptTokens: int(resp.UsageMetadata.PromptTokenCount),
+			OutputTokens: int(resp.UsageMetadata.CandidatesTokenCount),
+		}
+	}
+
+	if len(resp.Candidates) == 0 {
+		return result
+	}
+	cand := resp.Candidates[0]
+	result.FinishReason = finishReason(cand.FinishReason)
+	for _, rating := range cand.SafetyRatings {
+		result.SafetyRatings = append(result.SafetyRatings, SafetyRating{
+			Category:    rating.Category.String(),
+			Probability: rating.Probability.String(),
+			Blocked:     rating.Blocked,
+		})
+	}
+	return result
+}
+
+func finishReason(reason genai.FinishReason) string {
+	switch reason {
+	case genai.FinishReasonStop:
+		return FinishStop
+	case genai.FinishReasonMaxTokens:
+		return FinishMaxTokens
+	case genai.FinishReasonSafety:
+		return FinishSafety
+	case genai.FinishReasonRecitation:
+		return FinishRecitation
+	case genai.FinishReasonUnspecified:
+		return ""
+	default:
+		return FinishOther
+	}
+}
+
+// responseText joins the text parts of the first candidate.
+func responseText(resp *genai.GenerateContentResponse) string {
+	if len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil {
+		return ""
+	}
+
+	var text strings.Builder
+	for _, part := range resp.Candidates[0].Content.Parts {
+		if t, ok := part.(genai.Text); ok {
+			text.WriteString(string(t))
+		}
+	}
+	return text.String()
+}
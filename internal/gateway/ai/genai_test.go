@@ -0,0 +1,98 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+func TestGenAI_Generate(t *testing.T) {
+	testTable := []struct {
+		name           string
+		response       string
+		expectedResult Result
+		expectedErr    error
+	}{
+		{
+			name: "joins all parts",
+			response: `{"candidates":[{"content":{"role":"model","parts":[{"text":"sum"},{"text":"mary"}]},"finishReason":"STOP",
+				"safetyRatings":[{"category":"HARM_CATEGORY_HARASSMENT","probability":"NEGLIGIBLE"}]}],
+				"usageMetadata":{"promptTokenCount":10,"candidatesTokenCount":3,"totalTokenCount":13}}`,
+			expectedResult: Result{
+				Text:         "summary",
+				Model:        "test-model",
+				FinishReason: FinishStop,
+				SafetyRatings: []SafetyRating{
+					{Category: "HarmCategoryHarassment", Probability: "HarmProbabilityNegligible"},
+				},
+				Usage: Usage{PromptTokens: 10, OutputTokens: 3},
+			},
+		},
+		{
+			name:           "truncated",
+			response:       `{"candidates":[{"content":{"role":"model","parts":[{"text":"summ"}]},"finishReason":"MAX_TOKENS"}]}`,
+			expectedResult: Result{Text: "summ", Model: "test-model", FinishReason: FinishMaxTokens},
+			expectedErr:    ErrTruncated,
+		},
+		{
+			name:        "blocked",
+			response:    `{"candidates":[{"finishReason":"SAFETY"}]}`,
+			expectedErr: ErrBlocked,
+		},
+		{
+			name:        "blocked prompt",
+			response:    `{"promptFeedback":{"blockReason":"SAFETY"}}`,
+			expectedErr: ErrBlocked,
+		},
+		{
+			name:           "empty",
+			response:       `{"candidates":[{"content":{"role":"model","parts":[]},"finishReason":"STOP"}]}`,
+			expectedResult: Result{Model: "test-model", FinishReason: FinishStop},
+			expectedErr:    ErrEmptyResponse,
+		},
+	}
+
+	for _, tt := range testTable {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.True(t, strings.HasSuffix(r.URL.Path, "models/test-model:generateContent"), r.URL.Path)
+
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprint(w, tt.response)
+			}))
+			defer srv.Close()
+
+			log := slog.New(slog.NewTextHandler(io.Discard, nil))
+			a, err := New(context.Background(), Config{
+				Provider: ProviderGenAI,
+				Model:    "test-model",
+				Key:      "secret",
+				BaseURL:  srv.URL,
+			}, rate.NewLimiter(rate.Inf, 1), log)
+			require.NoError(t, err)
+			defer a.Close()
+
+			result, err := a.Generate(context.Background(), "prompt", "instruction", 0.5)
+			if tt.expectedErr != nil {
+				require.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tt.expectedResult, result)
+		})
+	}
+}
+
+func TestNew_UnknownProvider(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	_, err := New(context.Background(), Config{Provider: "nope"}, rate.NewLimiter(rate.Inf, 1), log)
+	require.Error(t, err)
+}
@@ -0,0 +1,355 @@
+package ai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const ProviderOpenAI = "openai"
+
+func init() {
+	Register(ProviderOpenAI, func(ctx context.Context, cfg Config, limiter *rate.Limiter, log *slog.Logger) (AI, error) {
+		if cfg.BaseURL == "" {
+			return nil, fmt.Errorf("openai provider requires base_url")
+		}
+		// Client.Timeout would also cover reading a long stream, so the transport only limits
+		// the wait for the response headers and the calls limit the rest themselves.
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.ResponseHeaderTimeout = cfg.Timeout
+		client := &http.Client{Transport: transport}
+		return NewOpenAI(cfg.BaseURL, cfg.Key, cfg.Model, client, cfg.Timeout, limiter, log), nil
+	})
+}
+
+// openAI talks to any server implementing the OpenAI chat completions API,
+// such as vLLM, the llama.cpp server or Ollama.
+type openAI struct {
+	baseURL string
+	key     string
+	model   string
+	client  *http.Client
+	// timeout limits a whole Generate or GenerateJSON call, and the silence between two events
+	// of a stream. Zero means no limit.
+	timeout time.Duration
+	limiter *rate.Limiter
+	log     *slog.Logger
+}
+
+func NewOpenAI(baseURL, key, model string, client *http.Client, timeout time.Duration, limiter *rate.Limiter, log *slog.Logger) *openAI {
+	return &openAI{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		key:     key,
+		model:   model,
+		client:  client,
+		timeout: timeout,
+		limiter: limiter,
+		log:     log,
+	}
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatRequest struct {
+	Model          string          `json:"model"`
+	Messages       []chatMessage   `json:"messages"`
+	Temperature    float32         `json:"temperature"`
+	Stream         bool            `json:"stream,omitempty"`
+	StreamOptions  *streamOptions  `json:"stream_options,omitempty"`
+	ResponseFormat *responseFormat `json:"response_format,omitempty"`
+}
+
+type responseFormat struct {
+	Type       string      `json:"type"`
+	JSONSchema *jsonSchema `json:"json_schema,omitempty"`
+}
+
+type jsonSchema struct {
+	Name   string  `json:"name"`
+	Schema *Schema `json:"schema"`
+}
+
+type streamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message      chatMessage `json:"message"`
+		Delta        chatMessage `json:"delta"`
+		FinishReason string      `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+func (a *openAI) Generate(ctx context.Context, prompt, instruction string, temp float32) (Result, error) {
+	const op = "gateway.ai.openai.Generate"
+	return a.complete(ctx, op, a.newRequest(prompt, instruction, temp, false))
+}
+
+func (a *openAI) GenerateJSON(ctx context.Context, prompt, instruction string, temp float32, schema *Schema) (Result, error) {
+	const op = "gateway.ai.openai.GenerateJSON"
+	request := a.newRequest(prompt, instruction, temp, false)
+	request.ResponseFormat = &responseFormat{
+		Type:       "json_schema",
+		JSONSchema: &jsonSchema{Name: "response", Schema: schema},
+	}
+	return a.complete(ctx, op, request)
+}
+
+// complete sends a request that is answered at once and reads the first choice.
+func (a *openAI) complete(ctx context.Context, op string, request chatRequest) (Result, error) {
+	if err := a.limiter.Wait(ctx); err != nil {
+		a.log.Error(op, "error:", err)
+		return Result{}, err
+	}
+
+	ctx, cancel := withTimeout(ctx, a.timeout)
+	defer cancel()
+	body, err := a.post(ctx, request)
+	if err != nil {
+		a.log.Error(op, "error:", err)
+		return Result{}, err
+	}
+	defer body.Close()
+
+	var response chatResponse
+	if err := json.NewDecoder(body).Decode(&response); err != nil {
+		a.log.Error(op, "error:", err)
+		return Result{}, fmt.Errorf("%s: decode response: %w", op, err)
+	}
+
+	result := Result{Model: a.model}
+	result.addUsage(response)
+	if len(response.Choices) > 0 {
+		result.Text = response.Choices[0].Message.Content
+		result.FinishReason = openAIFinishReason(response.Choices[0].FinishReason)
+	}
+	if err := result.check(); err != nil {
+		a.log.Warn(op, "error:", err)
+		return result, err
+	}
+	return result, nil
+}
+
+func (a *openAI) GenerateStream(ctx context.Context, prompt, instruction string, temp float32, onChunk func(text string)) (Result, error) {
+	const op = "gateway.ai.openai.GenerateStream"
+	if err := a.limiter.Wait(ctx); err != nil {
+		a.log.Error(op, "error:", err)
+		return Result{}, err
+	}
+
+	// A stream may run for long, so only a stream that stays silent for the timeout is cut off.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	idle := startIdleTimer(a.timeout, cancel)
+	defer idle.stop()
+
+	body, err := a.post(ctx, a.newRequest(prompt, instruction, temp, true))
+	if err != nil {
+		err = idle.err(a.timeout, err)
+		a.log.Error(op, "error:", err)
+		return Result{}, err
+	}
+	defer body.Close()
+
+	result := Result{Model: a.model}
+	var text strings.Builder
+	done := false
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+	for scanner.Scan() {
+		idle.reset(a.timeout)
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			done = true
+			break
+		}
+
+		var event chatResponse
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			a.log.Error(op, "error:", err)
+			return Result{}, fmt.Errorf("%s: decode event: %w", op, err)
+		}
+		result.addUsage(event)
+		if len(event.Choices) == 0 {
+			continue
+		}
+		if reason := event.Choices[0].FinishReason; reason != "" {
+			result.FinishReason = openAIFinishReason(reason)
+		}
+		if event.Choices[0].Delta.Content == "" {
+			continue
+		}
+
+		chunk := event.Choices[0].Delta.Content
+		text.WriteString(chunk)
+		onChunk(chunk)
+	}
+	if err := scanner.Err(); err != nil {
+		err = idle.err(a.timeout, err)
+		a.log.Error(op, "error:", err)
+		return Result{}, err
+	}
+	// A stream that ends with neither [DONE] nor a finish reason was cut off.
+	if !done && result.FinishReason == "" {
+		err := fmt.Errorf("%s: stream ended early: %w", op, io.ErrUnexpectedEOF)
+		a.log.Error(op, "error:", err)
+		return Result{}, err
+	}
+
+	result.Text = text.String()
+	if err := result.check(); err != nil {
+		a.log.Warn(op, "error:", err)
+		return result, err
+	}
+	return result, nil
+}
+
+// CountTokens is not part of the chat completions API.
+func (a *openAI) CountTokens(ctx context.Context, text string) (int, error) {
+	return 0, ErrNotSupported
+}
+
+func (a *openAI) Close() error {
+	a.client.CloseIdleConnections()
+	return nil
+}
+
+// withTimeout limits a call that is answered at once. A zero timeout means no limit.
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// idleTimer cancels a stream that has not sent anything for the timeout.
+type idleTimer struct {
+	timer *time.Timer
+	fired atomic.Bool
+}
+
+// startIdleTimer calls cancel once the timeout passes without a reset. A zero timeout means
+// no limit.
+func startIdleTimer(timeout time.Duration, cancel context.CancelFunc) *idleTimer {
+	idle := &idleTimer{}
+	if timeout > 0 {
+		idle.timer = time.AfterFunc(timeout, func() {
+			idle.fired.Store(true)
+			cancel()
+		})
+	}
+	return idle
+}
+
+func (t *idleTimer) reset(timeout time.Duration) {
+	if t.timer != nil {
+		t.timer.Reset(timeout)
+	}
+}
+
+func (t *idleTimer) stop() {
+	if t.timer != nil {
+		t.timer.Stop()
+	}
+}
+
+// err reports a stream cut off by the timer as a timeout, which the chain retries.
+func (t *idleTimer) err(timeout time.Duration, err error) error {
+	if t.fired.Load() {
+		return fmt.Errorf("no data from the stream for %s: %w", timeout, os.ErrDeadlineExceeded)
+	}
+	return err
+}
+
+func (a *openAI) newRequest(prompt, instruction string, temp float32, stream bool) chatRequest {
+	request := chatRequest{
+		Model: a.model,
+		Messages: []chatMessage{
+			{Role: "system", Content: instruction},
+			{Role: "user", Content: prompt},
+		},
+		Temperature: temp,
+		Stream:      stream,
+	}
+	if stream {
+		request.StreamOptions = &streamOptions{IncludeUsage: true}
+	}
+	return request
+}
+
+func (r *Result) addUsage(response chatResponse) {
+	if response.Usage != nil {
+		r.Usage = Usage{
+			PromptTokens: response.Usage.PromptTokens,
+			OutputTokens: response.Usage.CompletionTokens,
+		}
+	}
+}
+
+func openAIFinishReason(reason string) string {
+	switch reason {
+	case "stop":
+		return FinishStop
+	case "length":
+		return FinishMaxTokens
+	case "content_filter":
+		return FinishSafety
+	case "":
+		return ""
+	default:
+		return FinishOther
+	}
+}
+
+// post sends a chat completions request and returns the body of a successful response.
+func (a *openAI) post(ctx context.Context, request chatRequest) (io.ReadCloser, error) {
+	payload, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.baseURL+"/chat/completions", bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if a.key != "" {
+		req.Header.Set("Authorization", "Bearer "+a.key)
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		return nil, &APIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(message))}
+	}
+
+	return resp.Body, nil
+}
@@ -0,0 +1,221 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+func newTestOpenAI(t *testing.T, handler http.HandlerFunc) *openAI {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return NewOpenAI(srv.URL+"/v1", "secret", "test-model", srv.Client(), 0, rate.NewLimiter(rate.Inf, 1), log)
+}
+
+func TestOpenAI_Generate(t *testing.T) {
+	testTable := []struct {
+		name           string
+		status         int
+		response       string
+		expectedText   string
+		expectedErr    error
+		expectedStatus int
+	}{
+		{
+			name:         "OK",
+			status:       http.StatusOK,
+			response:     `{"choices":[{"message":{"role":"assistant","content":"summary"},"finish_reason":"stop"}],"usage":{"prompt_tokens":10,"completion_tokens":3}}`,
+			expectedText: "summary",
+		},
+		{
+			name:        "no choices",
+			status:      http.StatusOK,
+			response:    `{"choices":[]}`,
+			expectedErr: ErrEmptyResponse,
+		},
+		{
+			name:         "truncated",
+			status:       http.StatusOK,
+			response:     `{"choices":[{"message":{"role":"assistant","content":"summ"},"finish_reason":"length"}]}`,
+			expectedText: "summ",
+			expectedErr:  ErrTruncated,
+		},
+		{
+			name:        "content filter",
+			status:      http.StatusOK,
+			response:    `{"choices":[{"message":{"role":"assistant","content":""},"finish_reason":"content_filter"}]}`,
+			expectedErr: ErrBlocked,
+		},
+		{
+			name:           "rate limited",
+			status:         http.StatusTooManyRequests,
+			response:       `{"error":"slow down"}`,
+			expectedStatus: http.StatusTooManyRequests,
+		},
+	}
+
+	for _, tt := range testTable {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newTestOpenAI(t, func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, "/v1/chat/completions", r.URL.Path)
+				require.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+
+				var req chatRequest
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+				require.Equal(t, "test-model", req.Model)
+				require.Equal(t, []chatMessage{
+					{Role: "system", Content: "instruction"},
+					{Role: "user", Content: "prompt"},
+				}, req.Messages)
+				require.False(t, req.Stream)
+
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.response)
+			})
+
+			result, err := a.Generate(context.Background(), "prompt", "instruction", 0.5)
+			if tt.expectedStatus != 0 {
+				var apiErr *APIError
+				require.ErrorAs(t, err, &apiErr)
+				require.Equal(t, tt.expectedStatus, apiErr.StatusCode)
+				return
+			}
+			if tt.expectedErr != nil {
+				require.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tt.expectedText, result.Text)
+		})
+	}
+}
+
+func TestOpenAI_GenerateStream(t *testing.T) {
+	a := newTestOpenAI(t, func(w http.ResponseWriter, r *http.Request) {
+		var req chatRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.True(t, req.Stream)
+		require.NotNil(t, req.StreamOptions)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, piece := range []string{"Hel", "lo", " world"} {
+			fmt.Fprintf(w, "data: {\"choices\":[{\"delta\":{\"content\":%q}}]}\n\n", piece)
+		}
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{},\"finish_reason\":\"stop\"}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[],\"usage\":{\"prompt_tokens\":10,\"completion_tokens\":3}}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	})
+
+	var pieces []string
+	result, err := a.GenerateStream(context.Background(), "prompt", "instruction", 0.5, func(text string) {
+		pieces = append(pieces, text)
+	})
+	require.NoError(t, err)
+	require.Equal(t, Result{
+		Text:         "Hello world",
+		Model:        "test-model",
+		FinishReason: FinishStop,
+		Usage:        Usage{PromptTokens: 10, OutputTokens: 3},
+	}, result)
+	require.Equal(t, []string{"Hel", "lo", " world"}, pieces)
+}
+
+func TestOpenAI_GenerateStreamCutOff(t *testing.T) {
+	a := newTestOpenAI(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"Hel\"}}]}\n\n")
+	})
+
+	_, err := a.GenerateStream(context.Background(), "prompt", "instruction", 0.5, func(string) {})
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	require.True(t, IsRetryable(err))
+}
+
+func TestOpenAI_GenerateJSON(t *testing.T) {
+	schema := &Schema{
+		Type:       TypeObject,
+		Properties: map[string]*Schema{"title": {Type: TypeString}},
+		Required:   []string{"title"},
+	}
+
+	a := newTestOpenAI(t, func(w http.ResponseWriter, r *http.Request) {
+		var req chatRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.NotNil(t, req.ResponseFormat)
+		require.Equal(t, "json_schema", req.ResponseFormat.Type)
+		require.Equal(t, schema, req.ResponseFormat.JSONSchema.Schema)
+
+		fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"{\"title\":\"T\"}"},"finish_reason":"stop"}]}`)
+	})
+
+	result, err := a.GenerateJSON(context.Background(), "prompt", "instruction", 0.5, schema)
+	require.NoError(t, err)
+	require.Equal(t, `{"title":"T"}`, result.Text)
+}
+
+func TestOpenAI_Timeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req chatRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		if !req.Stream {
+			time.Sleep(200 * time.Millisecond)
+			fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"late"},"finish_reason":"stop"}]}`)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		stall := strings.HasPrefix(r.URL.Path, "/stall")
+		// Every piece comes well within the timeout, the whole answer does not.
+		for _, piece := range []string{"a", "b", "c", "d", "e"} {
+			time.Sleep(40 * time.Millisecond)
+			fmt.Fprintf(w, "data: {\"choices\":[{\"delta\":{\"content\":%q}}]}\n\n", piece)
+			w.(http.Flusher).Flush()
+			if stall {
+				<-r.Context().Done()
+				return
+			}
+		}
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{},\"finish_reason\":\"stop\"}]}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	t.Cleanup(srv.Close)
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	newAI := func(path string) *openAI {
+		return NewOpenAI(srv.URL+path, "", "test-model", srv.Client(), 100*time.Millisecond, rate.NewLimiter(rate.Inf, 1), log)
+	}
+
+	t.Run("long stream", func(t *testing.T) {
+		result, err := newAI("/v1").GenerateStream(context.Background(), "prompt", "instruction", 0.5, func(string) {})
+		require.NoError(t, err)
+		require.Equal(t, "abcde", result.Text)
+	})
+
+	t.Run("stalled stream", func(t *testing.T) {
+		_, err := newAI("/stall").GenerateStream(context.Background(), "prompt", "instruction", 0.5, func(string) {})
+		require.ErrorIs(t, err, os.ErrDeadlineExceeded)
+		require.True(t, IsRetryable(err))
+	})
+
+	t.Run("slow answer", func(t *testing.T) {
+		_, err := newAI("/v1").Generate(context.Background(), "prompt", "instruction", 0.5)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.True(t, IsRetryable(err))
+	})
+}
@@ -0,0 +1,64 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+type Config struct {
+	Provider string
+	Model    string
+	Key      string
+	BaseURL  string
+	Timeout  time.Duration
+}
+
+// Factory builds a provider backend. The limiter is shared by every backend of the process.
+type Factory func(ctx context.Context, cfg Config, limiter *rate.Limiter, log *slog.Logger) (AI, error)
+
+var (
+	providersMu sync.RWMutex
+	providers   = make(map[string]Factory)
+)
+
+// Register makes a provider available under name. It panics if the name is taken.
+func Register(name string, factory Factory) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+
+	if _, ok := providers[name]; ok {
+		panic("ai: provider registered twice: " + name)
+	}
+	providers[name] = factory
+}
+
+// New creates the backend selected by cfg.Provider.
+func New(ctx context.Context, cfg Config, limiter *rate.Limiter, log *slog.Logger) (AI, error) {
+	providersMu.RLock()
+	factory, ok := providers[cfg.Provider]
+	providersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown ai provider %q, available: %v", cfg.Provider, Providers())
+	}
+
+	return factory(ctx, cfg, limiter, log)
+}
+
+// Providers returns the names of the registered providers.
+func Providers() []string {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
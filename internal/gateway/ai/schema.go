@@ -0,0 +1,69 @@
+package ai
+
+import "github.com/google/generative-ai-go/genai"
+
+const (
+	TypeObject  = "object"
+	TypeArray   = "array"
+	TypeString  = "string"
+	TypeInteger = "integer"
+	TypeNumber  = "number"
+	TypeBoolean = "boolean"
+)
+
+// Schema describes the JSON object a model must answer with. It is the subset of JSON Schema
+// understood by every provider and marshals to JSON Schema as is.
+type Schema struct {
+	Type        string             `json:"type"`
+	Description string             `json:"description,omitempty"`
+	Enum        []string           `json:"enum,omitempty"`
+	Items       *Schema            `json:"items,omitempty"`
+	Properties  map[string]*Schema `json:"properties,omitempty"`
+	Required    []string           `json:"required,omitempty"`
+	// Validate, when set on the top-level schema, checks an answer the way the caller will.
+	// Cached GenerateJSON answers are kept only when it accepts them.
+	Validate func(text string) error `json:"-"`
+}
+
+func (s *Schema) genai() *genai.Schema {
+	if s == nil {
+		return nil
+	}
+
+	schema := &genai.Schema{
+		Type:        genaiType(s.Type),
+		Description: s.Description,
+		Enum:        s.Enum,
+		Items:       s.Items.genai(),
+		Required:    s.Required,
+	}
+	if len(s.Enum) > 0 {
+		schema.Format = "enum"
+	}
+	if len(s.Properties) > 0 {
+		schema.Properties = make(map[string]*genai.Schema, len(s.Properties))
+		for name, property := range s.Properties {
+			schema.Properties[name] = property.genai()
+		}
+	}
+	return schema
+}
+
+func genaiType(t string) genai.Type {
+	switch t {
+	case TypeObject:
+		return genai.TypeObject
+	case TypeArray:
+		return genai.TypeArray
+	case TypeString:
+		return genai.TypeString
+	case TypeInteger:
+		return genai.TypeInteger
+	case TypeNumber:
+		return genai.TypeNumber
+	case TypeBoolean:
+		return genai.TypeBoolean
+	default:
+		return genai.TypeUnspecified
+	}
+}
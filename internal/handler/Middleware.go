@@ -34,3 +34,28 @@ func (h *handler) authMiddleware(c *gin.Context) {
 	c.Set("userID", id)
 	c.Next()
 }
+
+// anonymousAuthMiddleware authenticates the caller, but lets requests without an
+// Authorization header through as anonymous when the anonymous tier is enabled.
+func (h *handler) anonymousAuthMiddleware(c *gin.Context) {
+	if c.GetHeader("Authorization") != "" || h.anonymous == nil {
+		h.authMiddleware(c)
+		return
+	}
+	c.Next()
+}
+
+// anonymousRateLimiter applies the much tighter per-IP limit of the anonymous tier
+// to requests that were not authenticated.
+func (h *handler) anonymousRateLimiter(c *gin.Context) {
+	if _, ok := c.Get("userID"); ok {
+		c.Next()
+		return
+	}
+
+	if !h.anonymous.getVisitor(c.ClientIP()).Allow() {
+		newHTTPError(c, http.StatusTooManyRequests, "too many requests, sign in for higher limits")
+		return
+	}
+	c.Next()
+}
@@ -1,20 +1,28 @@
 package handler
 
 import (
+	"todoai/internal/config"
 	"todoai/internal/service"
 	"todoai/pkg/jwt"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
 )
 
 type handler struct {
 	service *service.Service
 	jwt     jwt.JWT
+	// anonymous rate limits callers without an access token; nil when they are not allowed.
+	anonymous *ipLimiter
 }
 
-func NewHandler(service *service.Service, jwt jwt.JWT) *handler {
-	return &handler{service: service, jwt: jwt}
+func NewHandler(service *service.Service, jwt jwt.JWT, cfg *config.Config) *handler {
+	h := &handler{service: service, jwt: jwt}
+	if cfg.Anonymous.Enabled {
+		h.anonymous = newIPLimiter(rate.Limit(cfg.Anonymous.RateLimit), cfg.Anonymous.Burst)
+	}
+	return h
 }
 
 func (h *handler) HandlerRegistrator() *gin.Engine {
@@ -44,14 +52,50 @@ func (h *handler) HandlerRegistrator() *gin.Engine {
 			lists.POST("/create", h.createList)
 			lists.PATCH("/update/:id", h.updateList)
 			lists.DELETE("/delete/:id", h.deleteList)
+			lists.POST("/generate/file", h.generateListFromFile)
+			lists.POST("/generate/text", h.generateListFromText)
+
+			lists.GET("/:id/items", h.getItems)
+			lists.POST("/:id/items", h.addItem)
+			lists.PUT("/:id/items/order", h.reorderItems)
+			lists.PUT("/:id/items/:itemId", h.updateItem)
+			lists.POST("/:id/items/:itemId/complete", h.completeItem)
+			lists.POST("/:id/items/:itemId/uncomplete", h.uncompleteItem)
+			lists.DELETE("/:id/items/:itemId", h.deleteItem)
+		}
+
+		summaries := api.Group("/summaries", h.authMiddleware)
+		{
+			summaries.GET("", h.getSummaries)
+			summaries.GET("/:id", h.getSummaryById)
+			summaries.DELETE("/:id", h.deleteSummary)
+			summaries.POST("/:id/list", h.saveSummaryAsList)
 		}
 
+		api.GET("/usage", h.authMiddleware, h.getUsage)
+		api.GET("/search", h.authMiddleware, h.search)
+
+		api.GET("/modes", h.anonymousAuthMiddleware, h.getModes)
+		modes := api.Group("/modes", h.authMiddleware)
+		{
+			modes.POST("", h.createMode)
+			modes.PUT("/:id", h.updateMode)
+			modes.DELETE("/:id", h.deleteMode)
+		}
 	}
 
-	upload := api.Group("/upload")
+	upload := api.Group("/upload", h.anonymousAuthMiddleware, h.anonymousRateLimiter)
 	{
 		upload.POST("/file", h.uploadFile)
 		upload.POST("/text", h.uploadText)
+		upload.POST("/file/stream", h.streamFile)
+		upload.POST("/text/stream", h.streamText)
+	}
+
+	jobs := api.Group("/jobs", h.anonymousAuthMiddleware)
+	{
+		jobs.GET("/:id", h.getJob)
+		jobs.DELETE("/:id", h.deleteJob)
 	}
 
 	return r
@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"todoai/internal/models"
+	"todoai/internal/repository"
+	"todoai/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type reorderItemsRequest struct {
+	ItemIDs []int `json:"item_ids" binding:"required"`
+}
+
+func (h *handler) getItems(c *gin.Context) {
+	listID, ok := listIDParam(c)
+	if !ok {
+		return
+	}
+
+	userID := c.GetInt("userID")
+	items, err := h.service.Items.Get(c.Request.Context(), listID, userID)
+	if err != nil {
+		itemError(c, err, "failed to get items")
+		return
+	}
+	c.JSON(http.StatusOK, items)
+}
+
+func (h *handler) addItem(c *gin.Context) {
+	listID, ok := listIDParam(c)
+	if !ok {
+		return
+	}
+
+	var item models.Item
+	if err := c.ShouldBindJSON(&item); err != nil {
+		newHTTPError(c, http.StatusBadRequest, "invalid item data")
+		return
+	}
+	item.ID, item.ListID = 0, listID
+
+	userID := c.GetInt("userID")
+	if err := h.service.Items.Add(c.Request.Context(), &item, userID); err != nil {
+		itemError(c, err, "failed to add item")
+		return
+	}
+	c.JSON(http.StatusCreated, item)
+}
+
+func (h *handler) updateItem(c *gin.Context) {
+	listID, itemID, ok := itemIDParams(c)
+	if !ok {
+		return
+	}
+
+	var item models.Item
+	if err := c.ShouldBindJSON(&item); err != nil {
+		newHTTPError(c, http.StatusBadRequest, "invalid item data")
+		return
+	}
+	item.ID, item.ListID = itemID, listID
+
+	userID := c.GetInt("userID")
+	if err := h.service.Items.Update(c.Request.Context(), &item, userID); err != nil {
+		itemError(c, err, "failed to update item")
+		return
+	}
+	c.JSON(http.StatusOK, item)
+}
+
+func (h *handler) completeItem(c *gin.Context) {
+	h.setItemDone(c, true)
+}
+
+func (h *handler) uncompleteItem(c *gin.Context) {
+	h.setItemDone(c, false)
+}
+
+func (h *handler) setItemDone(c *gin.Context, done bool) {
+	listID, itemID, ok := itemIDParams(c)
+	if !ok {
+		return
+	}
+
+	userID := c.GetInt("userID")
+	item, err := h.service.Items.SetDone(c.Request.Context(), listID, itemID, done, userID)
+	if err != nil {
+		itemError(c, err, "failed to update item")
+		return
+	}
+	c.JSON(http.StatusOK, item)
+}
+
+func (h *handler) reorderItems(c *gin.Context) {
+	listID, ok := listIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req reorderItemsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		newHTTPError(c, http.StatusBadRequest, "invalid item order")
+		return
+	}
+
+	userID := c.GetInt("userID")
+	items, err := h.service.Items.Reorder(c.Request.Context(), listID, req.ItemIDs, userID)
+	if err != nil {
+		itemError(c, err, "failed to reorder items")
+		return
+	}
+	c.JSON(http.StatusOK, items)
+}
+
+func (h *handler) deleteItem(c *gin.Context) {
+	listID, itemID, ok := itemIDParams(c)
+	if !ok {
+		return
+	}
+
+	userID := c.GetInt("userID")
+	if err := h.service.Items.Delete(c.Request.Context(), listID, itemID, userID); err != nil {
+		itemError(c, err, "failed to delete item")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "item deleted successfully"})
+}
+
+func listIDParam(c *gin.Context) (int, bool) {
+	listID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newHTTPError(c, http.StatusBadRequest, "invalid list id")
+		return 0, false
+	}
+	return listID, true
+}
+
+func itemIDParams(c *gin.Context) (int, int, bool) {
+	listID, ok := listIDParam(c)
+	if !ok {
+		return 0, 0, false
+	}
+	itemID, err := strconv.Atoi(c.Param("itemId"))
+	if err != nil {
+		newHTTPError(c, http.StatusBadRequest, "invalid item id")
+		return 0, 0, false
+	}
+	return listID, itemID, true
+}
+
+func itemError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidOrder):
+		newHTTPError(c, http.StatusBadRequest, err.Error())
+	case errors.Is(err, repository.ErrListNotFound):
+		newHTTPError(c, http.StatusNotFound, "list not found")
+	case errors.Is(err, repository.ErrItemNotFound):
+		newHTTPError(c, http.StatusNotFound, "item not found")
+	default:
+		newHTTPError(c, http.StatusInternalServerError, message)
+	}
+}
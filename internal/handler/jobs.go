@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"todoai/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func (h *handler) getJob(c *gin.Context) {
+	jobID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		newHTTPError(c, http.StatusBadRequest, "invalid job id")
+		return
+	}
+
+	userID := c.GetInt("userID")
+	job, err := h.service.Jobs.Get(c.Request.Context(), jobID.String(), userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrJobNotFound) {
+			newHTTPError(c, http.StatusNotFound, "job not found")
+			return
+		}
+		newHTTPError(c, http.StatusInternalServerError, "failed to get job")
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+func (h *handler) deleteJob(c *gin.Context) {
+	jobID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		newHTTPError(c, http.StatusBadRequest, "invalid job id")
+		return
+	}
+
+	userID := c.GetInt("userID")
+	if err := h.service.Jobs.Cancel(c.Request.Context(), jobID.String(), userID); err != nil {
+		if errors.Is(err, repository.ErrJobNotFound) {
+			newHTTPError(c, http.StatusNotFound, "job not found")
+			return
+		}
+		newHTTPError(c, http.StatusInternalServerError, "failed to delete job")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "job deleted successfully"})
+}
@@ -1,9 +1,12 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 	"todoai/internal/models"
+	"todoai/internal/repository"
 
 	"github.com/gin-gonic/gin"
 )
@@ -17,19 +20,34 @@ func (h *handler) getListById(c *gin.Context) {
 	userID := c.GetInt("userID")
 	list, err := h.service.Lists.GetByID(c.Request.Context(), listID, userID)
 	if err != nil {
+		if errors.Is(err, repository.ErrListNotFound) {
+			newHTTPError(c, http.StatusNotFound, "list not found")
+			return
+		}
 		newHTTPError(c, http.StatusInternalServerError, "failed to get list by id")
 		return
 	}
+	c.Header("ETag", listETag(list.Version))
 	c.JSON(http.StatusOK, list)
 }
 func (h *handler) getLists(c *gin.Context) {
+	var query models.ListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		newHTTPError(c, http.StatusBadRequest, "invalid list query")
+		return
+	}
+
 	userID := c.GetInt("userID")
-	lists, err := h.service.Lists.Get(c.Request.Context(), userID)
+	page, err := h.service.Lists.Get(c.Request.Context(), query, userID)
 	if err != nil {
+		if errors.Is(err, repository.ErrInvalidCursor) {
+			newHTTPError(c, http.StatusBadRequest, "invalid cursor")
+			return
+		}
 		newHTTPError(c, http.StatusInternalServerError, "failed to get lists")
 		return
 	}
-	c.JSON(http.StatusOK, lists)
+	c.JSON(http.StatusOK, page)
 }
 
 func (h *handler) createList(c *gin.Context) {
@@ -47,12 +65,55 @@ func (h *handler) createList(c *gin.Context) {
 	c.JSON(http.StatusCreated, gin.H{"message": "list created successfully"})
 }
 
+type generateListRequest struct {
+	Text string `json:"text" binding:"required"`
+}
+
+func (h *handler) generateListFromFile(c *gin.Context) {
+	file, ok := readUploadedFile(c)
+	if !ok {
+		return
+	}
+
+	userID := c.GetInt("userID")
+	list, err := h.service.Todo.GenerateFromFile(c.Request.Context(), file.bytes, file.filename, userID)
+	if err != nil {
+		code, message := summaryErrorResponse(err)
+		newHTTPError(c, code, message)
+		return
+	}
+	c.JSON(http.StatusCreated, list)
+}
+
+func (h *handler) generateListFromText(c *gin.Context) {
+	var req generateListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		newHTTPError(c, http.StatusBadRequest, "invalid response")
+		return
+	}
+
+	userID := c.GetInt("userID")
+	list, err := h.service.Todo.GenerateFromText(c.Request.Context(), req.Text, userID)
+	if err != nil {
+		code, message := summaryErrorResponse(err)
+		newHTTPError(c, code, message)
+		return
+	}
+	c.JSON(http.StatusCreated, list)
+}
+
+// updateList updates the title and body of the list that are sent. An If-Match header with the
+// ETag of the list makes the update fail with 412 when the list was changed in the meantime.
 func (h *handler) updateList(c *gin.Context) {
-	var list models.List
-	if err := c.ShouldBindJSON(&list); err != nil {
+	var update models.ListUpdate
+	if err := c.ShouldBindJSON(&update); err != nil {
 		newHTTPError(c, http.StatusBadRequest, "invalid list data")
 		return
 	}
+	if update.Title == nil && update.Body == nil {
+		newHTTPError(c, http.StatusBadRequest, "nothing to update")
+		return
+	}
 
 	userID := c.GetInt("userID")
 	listID, err := strconv.Atoi(c.Param("id"))
@@ -60,14 +121,53 @@ func (h *handler) updateList(c *gin.Context) {
 		newHTTPError(c, http.StatusBadRequest, "invalid list id")
 		return
 	}
+	version, ok := ifMatchVersion(c.GetHeader("If-Match"))
+	if !ok {
+		newHTTPError(c, http.StatusPreconditionFailed, "the list was changed, fetch it again")
+		return
+	}
 
-	err = h.service.Lists.UpdateOnlyBody(c.Request.Context(), listID, list.Body, userID)
+	list, err := h.service.Lists.Update(c.Request.Context(), listID, update, version, userID)
 	if err != nil {
-		newHTTPError(c, http.StatusInternalServerError, "failed to update list")
+		switch {
+		case errors.Is(err, repository.ErrListNotFound):
+			newHTTPError(c, http.StatusNotFound, "list not found")
+		case errors.Is(err, repository.ErrVersionConflict):
+			newHTTPError(c, http.StatusPreconditionFailed, "the list was changed, fetch it again")
+		default:
+			newHTTPError(c, http.StatusInternalServerError, "failed to update list")
+		}
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "list updated successfully"})
+	c.Header("ETag", listETag(list.Version))
+	c.JSON(http.StatusOK, list)
+}
+
+func listETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// ifMatchVersion returns the list version of an If-Match header, zero when any version matches.
+// Only a single strong ETag made by listETag is understood; anything else never matches.
+func ifMatchVersion(header string) (int, bool) {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return 0, true
+	}
+	tag, ok := strings.CutPrefix(header, `"`)
+	if !ok {
+		return 0, false
+	}
+	tag, ok = strings.CutSuffix(tag, `"`)
+	if !ok {
+		return 0, false
+	}
+	version, err := strconv.Atoi(tag)
+	if err != nil || version <= 0 {
+		return 0, false
+	}
+	return version, true
 }
 
 func (h *handler) deleteList(c *gin.Context) {
@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIfMatchVersion(t *testing.T) {
+	testTable := []struct {
+		name            string
+		header          string
+		expectedVersion int
+		expectedOK      bool
+	}{
+		{name: "no header", header: "", expectedVersion: 0, expectedOK: true},
+		{name: "any version", header: "*", expectedVersion: 0, expectedOK: true},
+		{name: "list etag", header: listETag(7), expectedVersion: 7, expectedOK: true},
+		{name: "weak etag", header: `W/"7"`, expectedOK: false},
+		{name: "several etags", header: `"7", "8"`, expectedOK: false},
+		{name: "unquoted", header: "7", expectedOK: false},
+		{name: "zero version", header: `"0"`, expectedOK: false},
+	}
+
+	for _, tt := range testTable {
+		t.Run(tt.name, func(t *testing.T) {
+			version, ok := ifMatchVersion(tt.header)
+			require.Equal(t, tt.expectedOK, ok)
+			require.Equal(t, tt.expectedVersion, version)
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"todoai/internal/models"
+	"todoai/internal/repository"
+	"todoai/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *handler) getModes(c *gin.Context) {
+	userID := c.GetInt("userID")
+	modes, err := h.service.Modes.Get(c.Request.Context(), userID)
+	if err != nil {
+		newHTTPError(c, http.StatusInternalServerError, "failed to get modes")
+		return
+	}
+	c.JSON(http.StatusOK, modes)
+}
+
+func (h *handler) createMode(c *gin.Context) {
+	var mode models.Mode
+	if err := c.ShouldBindJSON(&mode); err != nil {
+		newHTTPError(c, http.StatusBadRequest, "invalid mode data")
+		return
+	}
+
+	userID := c.GetInt("userID")
+	if err := h.service.Modes.Create(c.Request.Context(), &mode, userID); err != nil {
+		modeError(c, err, "failed to create mode")
+		return
+	}
+	c.JSON(http.StatusCreated, mode)
+}
+
+func (h *handler) updateMode(c *gin.Context) {
+	modeID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newHTTPError(c, http.StatusBadRequest, "invalid mode id")
+		return
+	}
+
+	var mode models.Mode
+	if err := c.ShouldBindJSON(&mode); err != nil {
+		newHTTPError(c, http.StatusBadRequest, "invalid mode data")
+		return
+	}
+	mode.ID = modeID
+
+	userID := c.GetInt("userID")
+	if err := h.service.Modes.Update(c.Request.Context(), &mode, userID); err != nil {
+		modeError(c, err, "failed to update mode")
+		return
+	}
+	c.JSON(http.StatusOK, mode)
+}
+
+func (h *handler) deleteMode(c *gin.Context) {
+	modeID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newHTTPError(c, http.StatusBadRequest, "invalid mode id")
+		return
+	}
+
+	userID := c.GetInt("userID")
+	if err := h.service.Modes.Delete(c.Request.Context(), modeID, userID); err != nil {
+		modeError(c, err, "failed to delete mode")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "mode deleted successfully"})
+}
+
+func modeError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidModeName), errors.Is(err, service.ErrBuiltInMode),
+		errors.Is(err, service.ErrInvalidPrompt):
+		newHTTPError(c, http.StatusBadRequest, err.Error())
+	case errors.Is(err, repository.ErrModeExists):
+		newHTTPError(c, http.StatusConflict, "mode with this name already exists")
+	case errors.Is(err, repository.ErrModeNotFound):
+		newHTTPError(c, http.StatusNotFound, "mode not found")
+	default:
+		newHTTPError(c, http.StatusInternalServerError, message)
+	}
+}
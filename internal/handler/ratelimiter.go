@@ -14,21 +14,34 @@ type visitor struct {
 	lastSeen time.Time
 }
 
-var visitors = make(map[string]*visitor)
-var mu sync.Mutex
+// ipLimiter keeps a token bucket per client IP and forgets IPs that have been idle for a while.
+type ipLimiter struct {
+	mu       sync.Mutex
+	visitors map[string]*visitor
+	limit    rate.Limit
+	burst    int
+}
 
-func init() {
-	go cleanupVisitors()
+func newIPLimiter(limit rate.Limit, burst int) *ipLimiter {
+	l := &ipLimiter{
+		visitors: make(map[string]*visitor),
+		limit:    limit,
+		burst:    burst,
+	}
+	go l.cleanupVisitors()
+	return l
 }
 
-func getVisitor(ip string) *rate.Limiter {
-	mu.Lock()
-	defer mu.Unlock()
+var visitors = newIPLimiter(1, 3)
+
+func (l *ipLimiter) getVisitor(ip string) *rate.Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 
-	v, exists := visitors[ip]
+	v, exists := l.visitors[ip]
 	if !exists {
-		limiter := rate.NewLimiter(1, 3)
-		visitors[ip] = &visitor{limiter, time.Now()}
+		limiter := rate.NewLimiter(l.limit, l.burst)
+		l.visitors[ip] = &visitor{limiter, time.Now()}
 		return limiter
 	}
 
@@ -36,24 +49,24 @@ func getVisitor(ip string) *rate.Limiter {
 	return v.limiter
 }
 
-func cleanupVisitors() {
+func (l *ipLimiter) cleanupVisitors() {
 	for {
 		time.Sleep(time.Minute)
 
-		mu.Lock()
-		for ip, v := range visitors {
+		l.mu.Lock()
+		for ip, v := range l.visitors {
 			if time.Since(v.lastSeen) > 3*time.Minute {
-				delete(visitors, ip)
+				delete(l.visitors, ip)
 			}
 		}
-		mu.Unlock()
+		l.mu.Unlock()
 	}
 }
 
 func (h *handler) rateLimiter(c *gin.Context) {
 	ip := c.ClientIP()
 
-	limiter := getVisitor(ip)
+	limiter := visitors.getVisitor(ip)
 	if !limiter.Allow() {
 		newHTTPError(c, http.StatusTooManyRequests, "too many requests")
 		return
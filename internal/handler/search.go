@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"todoai/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *handler) search(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+	if err != nil || limit < 0 {
+		newHTTPError(c, http.StatusBadRequest, "invalid limit")
+		return
+	}
+
+	userID := c.GetInt("userID")
+	results, err := h.service.Search.Search(c.Request.Context(), c.Query("q"), limit, userID)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidSearchQuery) {
+			newHTTPError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		newHTTPError(c, http.StatusInternalServerError, "failed to search")
+		return
+	}
+	c.JSON(http.StatusOK, results)
+}
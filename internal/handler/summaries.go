@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"todoai/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *handler) getSummaries(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+	if err != nil || limit < 0 {
+		newHTTPError(c, http.StatusBadRequest, "invalid limit")
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		newHTTPError(c, http.StatusBadRequest, "invalid offset")
+		return
+	}
+
+	userID := c.GetInt("userID")
+	page, err := h.service.Summaries.Get(c.Request.Context(), limit, offset, userID)
+	if err != nil {
+		newHTTPError(c, http.StatusInternalServerError, "failed to get summaries")
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+func (h *handler) getSummaryById(c *gin.Context) {
+	summaryID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newHTTPError(c, http.StatusBadRequest, "invalid summary id")
+		return
+	}
+
+	userID := c.GetInt("userID")
+	summary, err := h.service.Summaries.GetByID(c.Request.Context(), summaryID, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrSummaryNotFound) {
+			newHTTPError(c, http.StatusNotFound, "summary not found")
+			return
+		}
+		newHTTPError(c, http.StatusInternalServerError, "failed to get summary")
+		return
+	}
+	c.JSON(http.StatusOK, summary)
+}
+
+func (h *handler) deleteSummary(c *gin.Context) {
+	summaryID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newHTTPError(c, http.StatusBadRequest, "invalid summary id")
+		return
+	}
+
+	userID := c.GetInt("userID")
+	if err := h.service.Summaries.Delete(c.Request.Context(), summaryID, userID); err != nil {
+		if errors.Is(err, repository.ErrSummaryNotFound) {
+			newHTTPError(c, http.StatusNotFound, "summary not found")
+			return
+		}
+		newHTTPError(c, http.StatusInternalServerError, "failed to delete summary")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "summary deleted successfully"})
+}
+
+type saveAsListRequest struct {
+	Title string `json:"title" binding:"max=64"`
+}
+
+func (h *handler) saveSummaryAsList(c *gin.Context) {
+	summaryID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newHTTPError(c, http.StatusBadRequest, "invalid summary id")
+		return
+	}
+
+	// The body is optional: without a title the list is named after the summary.
+	var req saveAsListRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		newHTTPError(c, http.StatusBadRequest, "invalid list data")
+		return
+	}
+
+	userID := c.GetInt("userID")
+	if err := h.service.Summaries.SaveAsList(c.Request.Context(), summaryID, req.Title, userID); err != nil {
+		if errors.Is(err, repository.ErrSummaryNotFound) {
+			newHTTPError(c, http.StatusNotFound, "summary not found")
+			return
+		}
+		newHTTPError(c, http.StatusInternalServerError, "failed to create list")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "list created successfully"})
+}
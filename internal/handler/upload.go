@@ -1,92 +1,285 @@
 package handler
 
 import (
+	"context"
 	"errors"
 	"io"
 	"net/http"
+	"strconv"
+	"time"
 	"todoai/internal/models"
 	"todoai/internal/service"
+	"todoai/pkg/lang"
+	"todoai/pkg/length"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 )
 
+type uploadedFile struct {
+	bytes    []byte
+	filename string
+	opts     models.SummaryOptions
+}
+
 func (h *handler) uploadFile(c *gin.Context) {
-	mode := c.DefaultPostForm("mode", "default")
-	if !isValidMode(mode) {
-		newHTTPError(c, http.StatusBadRequest, "invalid mode")
+	file, ok := readUploadedFile(c)
+	if !ok {
 		return
 	}
 
-	err := c.Request.ParseMultipartForm(100 << 20)
+	userID := c.GetInt("userID")
+	jobID, err := h.service.Jobs.SubmitFile(c.Request.Context(), file.bytes, file.filename, file.opts, userID)
 	if err != nil {
-		newHTTPError(c, http.StatusBadRequest, "file upload failed")
+		if errors.Is(err, service.ErrQueueFull) {
+			newHTTPError(c, http.StatusServiceUnavailable, service.ErrQueueFull.Error())
+			return
+		}
+		if errors.Is(err, service.ErrQuotaExceeded) {
+			newHTTPError(c, http.StatusTooManyRequests, service.ErrQuotaExceeded.Error())
+			return
+		}
+		if errors.Is(err, service.ErrUnknownMode) {
+			newHTTPError(c, http.StatusBadRequest, "invalid mode")
+			return
+		}
+		newHTTPError(c, http.StatusInternalServerError, "file upload failed")
 		return
 	}
 
-	file, handler, err := c.Request.FormFile("file")
-	if err != nil {
-		newHTTPError(c, http.StatusBadRequest, "file upload failed")
-		return
-	}
-	defer file.Close()
+	c.JSON(http.StatusAccepted, gin.H{"job_id": jobID})
+}
 
-	fileBytes, err := io.ReadAll(file)
-	if err != nil {
-		newHTTPError(c, http.StatusInternalServerError, "file upload failed")
+func (h *handler) uploadText(c *gin.Context) {
+	document, ok := readDocument(c)
+	if !ok {
 		return
 	}
 
-	text, err := h.service.Upload.ProcessFile(c.Request.Context(), fileBytes, handler.Filename, mode)
+	userID := c.GetInt("userID")
+	jobID, err := h.service.Jobs.SubmitText(c.Request.Context(), document.Text, document.SummaryOptions, userID)
 	if err != nil {
-		if errors.Is(err, service.ErrFileTooLarge) {
-			newHTTPError(c, http.StatusBadRequest, service.ErrFileTooLarge.Error())
+		if errors.Is(err, service.ErrQueueFull) {
+			newHTTPError(c, http.StatusServiceUnavailable, service.ErrQueueFull.Error())
 			return
 		}
-		newHTTPError(c, http.StatusInternalServerError, "file upload failed")
+		if errors.Is(err, service.ErrQuotaExceeded) {
+			newHTTPError(c, http.StatusTooManyRequests, service.ErrQuotaExceeded.Error())
+			return
+		}
+		if errors.Is(err, service.ErrUnknownMode) {
+			newHTTPError(c, http.StatusBadRequest, "invalid mode")
+			return
+		}
+		newHTTPError(c, http.StatusInternalServerError, "file upload text")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"text": text})
+	c.JSON(http.StatusAccepted, gin.H{"job_id": jobID})
 }
 
-func (h *handler) uploadText(c *gin.Context) {
-	var document models.Document
-	if err := c.ShouldBindJSON(&document); err != nil {
-		newHTTPError(c, http.StatusBadRequest, "invalid response")
+func (h *handler) streamFile(c *gin.Context) {
+	file, ok := readUploadedFile(c)
+	if !ok {
 		return
 	}
 
-	if !isValidMode(document.Mode) {
-		newHTTPError(c, http.StatusBadRequest, "invalid mode")
+	userID := c.GetInt("userID")
+	if !h.checkStream(c, file.opts.Mode, userID) {
 		return
 	}
 
-	text, err := h.service.Upload.ProcessText(c.Request.Context(), document.Text, document.Mode)
-	if err != nil {
-		if errors.Is(err, service.ErrFileTooLarge) {
-			newHTTPError(c, http.StatusBadRequest, service.ErrFileTooLarge.Error())
-			return
+	streamSummary(c, func(ctx context.Context) (models.Summary, error) {
+		return h.service.Upload.ProcessFile(ctx, file.bytes, file.filename, file.opts, userID)
+	})
+}
+
+func (h *handler) streamText(c *gin.Context) {
+	document, ok := readDocument(c)
+	if !ok {
+		return
+	}
+
+	userID := c.GetInt("userID")
+	if !h.checkStream(c, document.Mode, userID) {
+		return
+	}
+
+	streamSummary(c, func(ctx context.Context) (models.Summary, error) {
+		return h.service.Upload.ProcessText(ctx, document.Text, document.SummaryOptions, userID)
+	})
+}
+
+// checkStream rejects a streaming request with an unknown mode or an exhausted quota before
+// the event stream starts, while a 400 or a 429 can still be sent.
+func (h *handler) checkStream(c *gin.Context, mode string, userID int) bool {
+	if _, err := h.service.Modes.Resolve(c.Request.Context(), mode, userID); err != nil {
+		if errors.Is(err, service.ErrUnknownMode) {
+			newHTTPError(c, http.StatusBadRequest, "invalid mode")
+			return false
 		}
-		newHTTPError(c, http.StatusInternalServerError, "file upload text")
+		newHTTPError(c, http.StatusInternalServerError, "failed to get mode")
+		return false
+	}
+	if err := h.service.Usage.Check(c.Request.Context(), userID); err != nil {
+		if errors.Is(err, service.ErrQuotaExceeded) {
+			newHTTPError(c, http.StatusTooManyRequests, service.ErrQuotaExceeded.Error())
+			return false
+		}
+		newHTTPError(c, http.StatusInternalServerError, "failed to check usage")
+		return false
+	}
+	return true
+}
+
+// streamSummary runs process inside the request and reports its progress as Server-Sent Events:
+// "progress" after every chunk, "chunk" with every chunk summary, "reduce" before every level
+// of summary reduction, "token" with pieces of the final text, then a single "done" event with the
+// summary or an "error" event.
+func streamSummary(c *gin.Context, process func(ctx context.Context) (models.Summary, error)) {
+	// Summaries of long documents outlive the server write timeout.
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		newHTTPError(c, http.StatusInternalServerError, "streaming is not supported")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"text": text})
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	send := func(event string, data any) {
+		c.SSEvent(event, data)
+		c.Writer.Flush()
+	}
+
+	ctx := service.WithProgress(c.Request.Context(), &service.Progress{
+		ChunkDone: func(done, total int) {
+			percent := 0
+			if total > 0 {
+				percent = done * 100 / total
+			}
+			send("progress", gin.H{"done": done, "total": total, "percent": percent})
+		},
+		ChunkSummary: func(index int, summary string) {
+			send("chunk", gin.H{"index": index, "text": summary})
+		},
+		ReduceLevel: func(level, groups int) {
+			send("reduce", gin.H{"level": level, "groups": groups})
+		},
+		Token: func(text string) {
+			send("token", gin.H{"text": text})
+		},
+	})
+
+	summary, err := process(ctx)
+	if err != nil {
+		status, message := summaryErrorResponse(err)
+		send("error", gin.H{"error": message, "status": status})
+		return
+	}
+	send("done", summary)
 }
 
-func isValidMode(mode string) bool {
-	switch mode {
-	case "default":
-		return true
-	case "book":
-		return true
-	case "doc":
-		return true
-	case "article":
-		return true
-	case "jurisprudence":
-		return true
+// summaryErrorResponse picks the HTTP status and message describing a failed summary.
+func summaryErrorResponse(err error) (int, string) {
+	switch {
+	case errors.Is(err, service.ErrFileTooLarge):
+		return http.StatusRequestEntityTooLarge, service.ErrFileTooLarge.Error()
+	case errors.Is(err, service.ErrUnsupportedFileType):
+		return http.StatusUnsupportedMediaType, service.ErrUnsupportedFileType.Error()
+	case errors.Is(err, service.ErrQuotaExceeded):
+		return http.StatusTooManyRequests, service.ErrQuotaExceeded.Error()
+	case errors.Is(err, service.ErrUnknownMode):
+		return http.StatusBadRequest, "invalid mode"
+	case errors.Is(err, length.ErrInvalid):
+		return http.StatusBadRequest, length.ErrInvalid.Error()
+	case errors.Is(err, lang.ErrUnsupported):
+		return http.StatusBadRequest, "unsupported target language"
+	case errors.Is(err, service.ErrContentBlocked):
+		return http.StatusUnprocessableEntity, service.ErrContentBlocked.Error()
+	case errors.Is(err, service.ErrSummaryTruncated):
+		return http.StatusBadGateway, service.ErrSummaryTruncated.Error()
+	case errors.Is(err, service.ErrEmptySummary):
+		return http.StatusBadGateway, service.ErrEmptySummary.Error()
+	case errors.Is(err, service.ErrInvalidStructuredSummary):
+		return http.StatusBadGateway, service.ErrInvalidStructuredSummary.Error()
+	case errors.Is(err, service.ErrInvalidTodoList):
+		return http.StatusBadGateway, service.ErrInvalidTodoList.Error()
+	case errors.Is(err, service.ErrNoActionItems):
+		return http.StatusUnprocessableEntity, service.ErrNoActionItems.Error()
 	default:
+		return http.StatusInternalServerError, "file upload failed"
+	}
+}
+
+func readUploadedFile(c *gin.Context) (uploadedFile, bool) {
+	// An empty mode is resolved to the default one by the service.
+	opts := models.SummaryOptions{
+		Mode:           c.PostForm("mode"),
+		Output:         c.PostForm("output"),
+		Format:         c.PostForm("format"),
+		Length:         c.PostForm("length"),
+		TargetLanguage: c.PostForm("target_language"),
+	}
+	if citations := c.PostForm("citations"); citations != "" {
+		var err error
+		if opts.Citations, err = strconv.ParseBool(citations); err != nil {
+			newHTTPError(c, http.StatusBadRequest, "invalid options")
+			return uploadedFile{}, false
+		}
+	}
+	if err := binding.Validator.ValidateStruct(opts); err != nil {
+		newHTTPError(c, http.StatusBadRequest, "invalid options")
+		return uploadedFile{}, false
+	}
+	if !validOptions(c, opts) {
+		return uploadedFile{}, false
+	}
+
+	err := c.Request.ParseMultipartForm(100 << 20)
+	if err != nil {
+		newHTTPError(c, http.StatusBadRequest, "file upload failed")
+		return uploadedFile{}, false
+	}
+
+	file, handler, err := c.Request.FormFile("file")
+	if err != nil {
+		newHTTPError(c, http.StatusBadRequest, "file upload failed")
+		return uploadedFile{}, false
+	}
+	defer file.Close()
+
+	fileBytes, err := io.ReadAll(file)
+	if err != nil {
+		newHTTPError(c, http.StatusInternalServerError, "file upload failed")
+		return uploadedFile{}, false
+	}
+
+	return uploadedFile{bytes: fileBytes, filename: handler.Filename, opts: opts}, true
+}
+
+func readDocument(c *gin.Context) (models.Document, bool) {
+	var document models.Document
+	if err := c.ShouldBindJSON(&document); err != nil {
+		newHTTPError(c, http.StatusBadRequest, "invalid response")
+		return models.Document{}, false
+	}
+	if !validOptions(c, document.SummaryOptions) {
+		return models.Document{}, false
+	}
+
+	return document, true
+}
+
+// validOptions rejects a length or a target language the service would not accept.
+func validOptions(c *gin.Context, opts models.SummaryOptions) bool {
+	if _, err := length.Parse(opts.Length); err != nil {
+		newHTTPError(c, http.StatusBadRequest, err.Error())
+		return false
+	}
+	if _, err := lang.Parse(opts.TargetLanguage); err != nil {
+		newHTTPError(c, http.StatusBadRequest, "unsupported target language")
 		return false
 	}
+	return true
 }
@@ -0,0 +1,17 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *handler) getUsage(c *gin.Context) {
+	userID := c.GetInt("userID")
+	report, err := h.service.Usage.Get(c.Request.Context(), userID)
+	if err != nil {
+		newHTTPError(c, http.StatusInternalServerError, "failed to get usage")
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+type JobStatus string
+
+const (
+	JobPending JobStatus = "pending"
+	JobRunning JobStatus = "running"
+	JobDone    JobStatus = "done"
+	JobFailed  JobStatus = "failed"
+)
+
+type Job struct {
+	ID          string             `json:"id"`
+	UserID      int                `json:"-"`
+	Status      JobStatus          `json:"status"`
+	Mode        string             `json:"mode"`
+	Options     SummaryOptions     `json:"-"`
+	Filename    string             `json:"filename,omitempty"`
+	Source      []byte             `json:"-"`
+	ChunksDone  int                `json:"chunks_done"`
+	ChunksTotal int                `json:"chunks_total"`
+	Result      string             `json:"result,omitempty"`
+	Structured  *StructuredSummary `json:"structured,omitempty"`
+	Outline     []OutlineNode      `json:"outline,omitempty"`
+	Citations   []Citation         `json:"citations,omitempty"`
+	// SourceLanguage is the detected language of the document, set when the job is done.
+	SourceLanguage string      `json:"source_language,omitempty"`
+	SummaryID      int         `json:"summary_id,omitempty"`
+	Cache          *CacheStats `json:"cache,omitempty"`
+	Error          string      `json:"error,omitempty"`
+	CreatedAt      time.Time   `json:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at"`
+}
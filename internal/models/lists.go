@@ -1,7 +1,72 @@
 package models
 
+import "time"
+
 type List struct {
 	ID    int    `json:"id"`
-	Title string `json:"title" binding:"required,min=2,max=128"`
-	Body  string `json:"body" binding:"required"`
+	Title string `json:"title" binding:"required,min=2,max=64"`
+	// Body is the free-form description of the list.
+	Body string `json:"body" binding:"required"`
+	// Items are the items of the list in order. They are managed through /api/lists/:id/items.
+	Items     []Item    `json:"items,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// Version is incremented by every update of the title or body and is sent as the ETag of the list.
+	Version int `json:"version"`
+}
+
+// ListUpdate is a partial update of a list. Nil fields are left as they are.
+type ListUpdate struct {
+	Title *string `json:"title" binding:"omitempty,min=2,max=64"`
+	Body  *string `json:"body"`
+}
+
+const (
+	ListSortCreated = "created"
+	ListSortUpdated = "updated"
+	ListSortTitle   = "title"
+)
+
+// ListQuery selects a page of the user's lists.
+type ListQuery struct {
+	// Sort is created (the default), updated or title.
+	Sort string `form:"sort" binding:"omitempty,oneof=created updated title"`
+	// Order is asc or desc. Lists are sorted by date newest first and by title from A to Z by default.
+	Order string `form:"order" binding:"omitempty,oneof=asc desc"`
+	// Title keeps the lists whose title contains it, ignoring case.
+	Title string `form:"title" binding:"max=128"`
+	// Preview returns the beginning of every body instead of the whole of it.
+	Preview bool `form:"preview"`
+	Limit   int  `form:"limit" binding:"min=0"`
+	// Cursor is the NextCursor of the previous page; empty for the first page.
+	Cursor string `form:"cursor"`
+}
+
+// ListPage is a page of lists. NextCursor is empty on the last page.
+type ListPage struct {
+	Lists      []List `json:"lists"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+const (
+	PriorityLow    = "low"
+	PriorityMedium = "medium"
+	PriorityHigh   = "high"
+)
+
+// Priorities are the priorities an item may have; an item without one has an empty priority.
+var Priorities = []string{PriorityLow, PriorityMedium, PriorityHigh}
+
+// Item is an item of a list that can be ticked off.
+type Item struct {
+	ID     int    `json:"id"`
+	ListID int    `json:"list_id"`
+	Text   string `json:"text" binding:"required,max=256"`
+	// Due is the due date as YYYY-MM-DD, empty when there is none.
+	Due      string `json:"due,omitempty" binding:"omitempty,datetime=2006-01-02"`
+	Owner    string `json:"owner,omitempty" binding:"max=128"`
+	Priority string `json:"priority,omitempty" binding:"omitempty,oneof=low medium high"`
+	Done     bool   `json:"done"`
+	// Position orders the items of a list, starting from zero.
+	Position int `json:"position"`
 }
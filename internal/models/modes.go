@@ -0,0 +1,14 @@
+package models
+
+// Mode is a summarization mode: the prompts of the compressor, which summarizes every chunk,
+// and of the formatter, which writes the final text. Built-in modes come from the config and
+// have no ID; user modes are visible to their owner only.
+type Mode struct {
+	ID               int      `json:"id,omitempty"`
+	Name             string   `json:"name" binding:"required,min=2,max=64"`
+	CompressorPrompt string   `json:"compressor_prompt" binding:"required,max=4000"`
+	FormatterPrompt  string   `json:"formatter_prompt" binding:"required,max=4000"`
+	CompressTemp     *float32 `json:"compress_temp,omitempty" binding:"omitempty,gte=0,lte=1"`
+	FormatTemp       *float32 `json:"format_temp,omitempty" binding:"omitempty,gte=0,lte=1"`
+	BuiltIn          bool     `json:"built_in"`
+}
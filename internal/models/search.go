@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+const (
+	SearchKindList    = "list"
+	SearchKindSummary = "summary"
+)
+
+// SearchResult is a list or a summary matching a search query.
+type SearchResult struct {
+	// Kind is list or summary; ID is the ID of the list or of the summary.
+	Kind string `json:"kind"`
+	ID   int    `json:"id"`
+	// Title is the title of a list or the file name of a summary.
+	Title string `json:"title"`
+	// Snippet is the best matching fragments of the text as HTML: the text is escaped and the
+	// matched words are wrapped in <mark> tags. Title is plain text.
+	Snippet   string    `json:"snippet"`
+	Rank      float64   `json:"rank"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,33 @@
+package models
+
+// StructuredSummary is the summary returned for output=json.
+type StructuredSummary struct {
+	Title     string           `json:"title"`
+	Abstract  string           `json:"abstract"`
+	KeyPoints []string         `json:"key_points"`
+	Entities  []Entity         `json:"entities"`
+	Language  string           `json:"language"`
+	Sections  []SectionSummary `json:"sections"`
+}
+
+const (
+	EntityPerson       = "person"
+	EntityOrganization = "organization"
+	EntityLocation     = "location"
+	EntityDate         = "date"
+	EntityOther        = "other"
+)
+
+// EntityTypes lists the allowed values of Entity.Type.
+var EntityTypes = []string{EntityPerson, EntityOrganization, EntityLocation, EntityDate, EntityOther}
+
+// Entity is a named entity mentioned in the document.
+type Entity struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type SectionSummary struct {
+	Title   string `json:"title"`
+	Summary string `json:"summary"`
+}
@@ -0,0 +1,62 @@
+package models
+
+import "time"
+
+type Summary struct {
+	ID       int    `json:"id"`
+	Filename string `json:"filename,omitempty"`
+	Mode     string `json:"mode"`
+	TextHash string `json:"text_hash"`
+	Text     string `json:"text"`
+	Format   string `json:"format,omitempty"`
+	// SourceLanguage is the detected ISO 639-1 code of the document language, empty when
+	// it could not be detected. TargetLanguage is the code the summary was translated to.
+	SourceLanguage string `json:"source_language,omitempty"`
+	TargetLanguage string `json:"target_language,omitempty"`
+	// Structured is set for output=json; Text then holds it rendered as plain text.
+	Structured *StructuredSummary `json:"structured,omitempty"`
+	// Outline is the tree of the Markdown in Text for format=outline.
+	Outline []OutlineNode `json:"outline,omitempty"`
+	// Citations link the sentences of Text to the source when the request asked for them.
+	Citations  []Citation `json:"citations,omitempty"`
+	Model      string     `json:"model"`
+	DurationMs int64      `json:"duration_ms"`
+	CreatedAt  time.Time  `json:"created_at"`
+	// Cache reports how many model calls were answered from the cache. It is only set on
+	// a freshly made summary and only when the cache is enabled.
+	Cache *CacheStats `json:"cache,omitempty"`
+}
+
+// OutlineNode is a heading or a bullet of an outline with the entries nested under it.
+type OutlineNode struct {
+	Title    string        `json:"title"`
+	Children []OutlineNode `json:"children,omitempty"`
+}
+
+// Citation links a sentence of a summary to the chunks of the source it is based on.
+type Citation struct {
+	Sentence string        `json:"sentence"`
+	Sources  []SourceRange `json:"sources"`
+}
+
+// SourceRange is a chunk of the source: its 1-based number, its character offsets in the
+// extracted text and, for documents with pages, the pages it starts and ends on.
+type SourceRange struct {
+	Chunk     int `json:"chunk"`
+	Start     int `json:"start"`
+	End       int `json:"end"`
+	PageStart int `json:"page_start,omitempty"`
+	PageEnd   int `json:"page_end,omitempty"`
+}
+
+type CacheStats struct {
+	Hits   int `json:"hits"`
+	Misses int `json:"misses"`
+}
+
+type SummaryPage struct {
+	Summaries []Summary `json:"summaries"`
+	Total     int       `json:"total"`
+	Limit     int       `json:"limit"`
+	Offset    int       `json:"offset"`
+}
@@ -1,6 +1,42 @@
 package models
 
+const (
+	OutputText = "text"
+	OutputJSON = "json"
+)
+
+const (
+	FormatParagraphs = "paragraphs"
+	FormatBullets    = "bullets"
+	FormatMarkdown   = "markdown"
+	FormatOutline    = "outline"
+)
+
+// Formats lists the layouts of a text summary.
+var Formats = []string{FormatParagraphs, FormatBullets, FormatMarkdown, FormatOutline}
+
+// SummaryOptions are the settings of a summary request. Empty fields take their defaults.
+type SummaryOptions struct {
+	Mode string `json:"mode,omitempty"`
+	// Output is OutputText for a free-text summary or OutputJSON for a StructuredSummary.
+	Output string `json:"output,omitempty" binding:"omitempty,oneof=text json"`
+	// Format is the layout of a text summary, FormatParagraphs by default. It is ignored for OutputJSON.
+	Format string `json:"format,omitempty" binding:"omitempty,oneof=paragraphs bullets markdown outline"`
+	// Length is short, medium or long, a word budget like 300w, a sentence budget like 12s or
+	// a share of the source like 0.1 or 10%. Empty means every chunk gets Summarizer.MaxSentences
+	// and the final text is not limited. The budget of a text summary is enforced once more after
+	// the fact unless it is streamed; for OutputJSON it only asks the model for an abstract of
+	// that length.
+	Length string `json:"length,omitempty"`
+	// TargetLanguage is the ISO 639-1 code or English name of the language of the summary.
+	// Empty means the language of the document.
+	TargetLanguage string `json:"target_language,omitempty"`
+	// Citations asks for every sentence of a text summary to be linked to the parts of the
+	// source it is based on. It is ignored for OutputJSON.
+	Citations bool `json:"citations,omitempty"`
+}
+
 type Document struct {
-	Mode string `json:"mode" binding:"required"`
+	SummaryOptions
 	Text string `json:"text" binding:"required"`
 }
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// TokenUsage is the number of model tokens spent on prompts and on generated output.
+type TokenUsage struct {
+	PromptTokens int `json:"prompt_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+func (u TokenUsage) Total() int {
+	return u.PromptTokens + u.OutputTokens
+}
+
+// UsagePeriod reports the tokens spent since the start of a quota period.
+// A zero Limit means the period is not limited.
+type UsagePeriod struct {
+	Since        time.Time `json:"since"`
+	PromptTokens int       `json:"prompt_tokens"`
+	OutputTokens int       `json:"output_tokens"`
+	TotalTokens  int       `json:"total_tokens"`
+	Limit        int       `json:"limit"`
+}
+
+type UsageReport struct {
+	Daily   UsagePeriod `json:"daily"`
+	Monthly UsagePeriod `json:"monthly"`
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// cacheRepo is a cache.Store kept in Postgres, shared by all instances of the server.
+type cacheRepo struct {
+	db Querier
+}
+
+func NewCacheRepo(db Querier) *cacheRepo {
+	return &cacheRepo{db: db}
+}
+
+func (r *cacheRepo) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	const op = "repository.GetCache"
+
+	const query = `
+		SELECT value FROM ai_cache
+		WHERE key = $1 AND expires_at > NOW()
+	`
+	var value []byte
+	if err := r.db.QueryRowContext(ctx, query, key).Scan(&value); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("%s: %w", op, err)
+	}
+	return value, true, nil
+}
+
+// Set stores the value. Expired entries are left to DeleteExpired.
+func (r *cacheRepo) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	const op = "repository.SetCache"
+
+	const query = `
+		INSERT INTO ai_cache (key, value, expires_at)
+		VALUES ($1, $2, NOW() + $3 * INTERVAL '1 millisecond')
+		ON CONFLICT (key) DO UPDATE
+		SET value = EXCLUDED.value, expires_at = EXCLUDED.expires_at
+	`
+	_, err := r.db.ExecContext(ctx, query, key, value, ttl.Milliseconds())
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// DeleteExpired deletes up to limit expired entries and returns how many it deleted. Rows
+// locked by another instance doing the same are skipped rather than waited for.
+func (r *cacheRepo) DeleteExpired(ctx context.Context, limit int) (int, error) {
+	const op = "repository.DeleteExpiredCache"
+
+	const query = `
+		DELETE FROM ai_cache
+		WHERE key IN (
+			SELECT key FROM ai_cache
+			WHERE expires_at <= NOW()
+			ORDER BY expires_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+	`
+	res, err := r.db.ExecContext(ctx, query, limit)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return int(deleted), nil
+}
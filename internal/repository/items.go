@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"todoai/internal/models"
+)
+
+var (
+	ErrListNotFound = errors.New("list not found")
+	ErrItemNotFound = errors.New("item not found")
+)
+
+// Items are the items of the user's lists. Every method checks that the list belongs to the user.
+type Items interface {
+	Create(ctx context.Context, item *models.Item, userID int) error
+	GetByList(ctx context.Context, listID int, userID int) ([]models.Item, error)
+	Update(ctx context.Context, item *models.Item, userID int) error
+	SetDone(ctx context.Context, listID, itemID int, done bool, userID int) (models.Item, error)
+	Reorder(ctx context.Context, listID int, itemIDs []int, userID int) error
+	Delete(ctx context.Context, listID, itemID int, userID int) error
+}
+
+type itemsRepo struct {
+	db Querier
+}
+
+func NewItemsRepo(db Querier) *itemsRepo {
+	return &itemsRepo{db: db}
+}
+
+// itemColumns are the columns read by scanItem.
+const itemColumns = `i.id, i.list_id, i.text, COALESCE(to_char(i.due, 'YYYY-MM-DD'), ''), i.owner, i.priority, i.done, i.position`
+
+func scanItem(row rowScanner) (models.Item, error) {
+	var item models.Item
+	err := row.Scan(&item.ID, &item.ListID, &item.Text, &item.Due, &item.Owner, &item.Priority, &item.Done, &item.Position)
+	return item, err
+}
+
+// Create appends the item to the end of its list and fills in its ID and position.
+// A list that does not belong to the user yields ErrListNotFound.
+func (r *itemsRepo) Create(ctx context.Context, item *models.Item, userID int) error {
+	const op = "repository.CreateItem"
+
+	const query = `
+		INSERT INTO list_items (list_id, text, due, owner, priority, done, position)
+		SELECT l.id, $2, NULLIF($3, '')::date, $4, $5, $6,
+		       COALESCE((SELECT MAX(position) + 1 FROM list_items WHERE list_id = l.id), 0)
+		FROM lists l
+		WHERE l.id = $1 AND l.user_id = $7
+		RETURNING id, position
+	`
+	err := r.db.QueryRowContext(ctx, query,
+		item.ListID, item.Text, item.Due, item.Owner, item.Priority, item.Done, userID,
+	).Scan(&item.ID, &item.Position)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%s: %w", op, ErrListNotFound)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// GetByList returns the items of the list in order.
+func (r *itemsRepo) GetByList(ctx context.Context, listID int, userID int) ([]models.Item, error) {
+	const op = "repository.GetItemsByList"
+
+	const query = `
+		SELECT ` + itemColumns + `
+		FROM list_items i
+		JOIN lists l ON l.id = i.list_id
+		WHERE i.list_id = $1 AND l.user_id = $2
+		ORDER BY i.position, i.id
+	`
+	rows, err := r.db.QueryContext(ctx, query, listID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	items := []models.Item{}
+	for rows.Next() {
+		item, err := scanItem(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// An empty result does not tell an empty list from a missing one.
+	if len(items) == 0 {
+		const existsQuery = `SELECT EXISTS (SELECT 1 FROM lists WHERE id = $1 AND user_id = $2)`
+		var exists bool
+		if err := r.db.QueryRowContext(ctx, existsQuery, listID, userID).Scan(&exists); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if !exists {
+			return nil, fmt.Errorf("%s: %w", op, ErrListNotFound)
+		}
+	}
+	return items, nil
+}
+
+// Update replaces the text, due date, owner and priority of the item and fills in the rest of it.
+func (r *itemsRepo) Update(ctx context.Context, item *models.Item, userID int) error {
+	const op = "repository.UpdateItem"
+
+	const query = `
+		UPDATE list_items i
+		SET text = $3, due = NULLIF($4, '')::date, owner = $5, priority = $6
+		FROM lists l
+		WHERE i.id = $2 AND i.list_id = $1 AND l.id = i.list_id AND l.user_id = $7
+		RETURNING ` + itemColumns
+	updated, err := scanItem(r.db.QueryRowContext(ctx, query,
+		item.ListID, item.ID, item.Text, item.Due, item.Owner, item.Priority, userID,
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%s: %w", op, ErrItemNotFound)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	*item = updated
+	return nil
+}
+
+// SetDone completes or uncompletes the item and returns it.
+func (r *itemsRepo) SetDone(ctx context.Context, listID, itemID int, done bool, userID int) (models.Item, error) {
+	const op = "repository.SetItemDone"
+
+	const query = `
+		UPDATE list_items i
+		SET done = $3
+		FROM lists l
+		WHERE i.id = $2 AND i.list_id = $1 AND l.id = i.list_id AND l.user_id = $4
+		RETURNING ` + itemColumns
+	item, err := scanItem(r.db.QueryRowContext(ctx, query, listID, itemID, done, userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Item{}, fmt.Errorf("%s: %w", op, ErrItemNotFound)
+		}
+		return models.Item{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return item, nil
+}
+
+// Reorder gives the items of the list the positions of their IDs in itemIDs.
+// Items of the list missing from itemIDs keep their positions.
+func (r *itemsRepo) Reorder(ctx context.Context, listID int, itemIDs []int, userID int) error {
+	const op = "repository.ReorderItems"
+
+	const query = `
+		UPDATE list_items i
+		SET position = o.position - 1
+		FROM unnest($2::int[]) WITH ORDINALITY AS o(id, position), lists l
+		WHERE i.id = o.id AND i.list_id = $1 AND l.id = i.list_id AND l.user_id = $3
+	`
+	ids := make([]int32, len(itemIDs))
+	for i, id := range itemIDs {
+		ids[i] = int32(id)
+	}
+	if _, err := r.db.ExecContext(ctx, query, listID, ids, userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (r *itemsRepo) Delete(ctx context.Context, listID, itemID int, userID int) error {
+	const op = "repository.DeleteItem"
+
+	const query = `
+		DELETE FROM list_items i
+		USING lists l
+		WHERE i.id = $2 AND i.list_id = $1 AND l.id = i.list_id AND l.user_id = $3
+	`
+	result, err := r.db.ExecContext(ctx, query, listID, itemID, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%s: %w", op, ErrItemNotFound)
+	}
+	return nil
+}
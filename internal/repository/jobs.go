@@ -0,0 +1,261 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+	"todoai/internal/models"
+)
+
+var ErrJobNotFound = errors.New("job not found")
+
+type Jobs interface {
+	Create(ctx context.Context, job *models.Job) error
+	// GetByID and Delete only see the jobs of userID; a zero userID means anonymous jobs.
+	GetByID(ctx context.Context, jobID string, userID int) (models.Job, error)
+	Claim(ctx context.Context, jobID string) (models.Job, error)
+	UpdateProgress(ctx context.Context, jobID string, done, total int) error
+	Complete(ctx context.Context, jobID string, summary models.Summary) error
+	Fail(ctx context.Context, jobID string, message string) error
+	Delete(ctx context.Context, jobID string, userID int) error
+	ResetUnfinished(ctx context.Context, staleAfter time.Duration) ([]string, error)
+	Release(ctx context.Context, jobID string) error
+}
+
+type jobsRepo struct {
+	db Querier
+}
+
+func NewJobsRepo(db Querier) *jobsRepo {
+	return &jobsRepo{db: db}
+}
+
+func (r *jobsRepo) Create(ctx context.Context, job *models.Job) error {
+	const op = "repository.CreateJob"
+
+	const query = `
+		INSERT INTO jobs (id, user_id, status, mode, options, filename, source)
+		VALUES ($1, NULLIF($2, 0), $3, $4, $5, $6, $7)
+	`
+	options, err := json.Marshal(job.Options)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	_, err = r.db.ExecContext(ctx, query, job.ID, job.UserID, models.JobPending, job.Mode, options, job.Filename, job.Source)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (r *jobsRepo) GetByID(ctx context.Context, jobID string, userID int) (models.Job, error) {
+	const op = "repository.GetJobByID"
+
+	const query = `
+		SELECT id, status, mode, filename, chunks_done, chunks_total,
+		       COALESCE(result, ''), structured, outline, citations, source_language, COALESCE(summary_id, 0),
+		       cache_hits, cache_misses,
+		       COALESCE(error, ''), created_at, updated_at
+		FROM jobs
+		WHERE id = $1 AND user_id IS NOT DISTINCT FROM NULLIF($2, 0)
+	`
+	var job models.Job
+	var cacheHits, cacheMisses sql.NullInt64
+	var structured, outline, citations []byte
+	err := r.db.QueryRowContext(ctx, query, jobID, userID).Scan(
+		&job.ID, &job.Status, &job.Mode, &job.Filename, &job.ChunksDone, &job.ChunksTotal, &job.Result,
+		&structured, &outline, &citations, &job.SourceLanguage, &job.SummaryID, &cacheHits, &cacheMisses,
+		&job.Error, &job.CreatedAt, &job.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Job{}, fmt.Errorf("%s: %w", op, ErrJobNotFound)
+		}
+		return models.Job{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := scanJSON(structured, &job.Structured); err != nil {
+		return models.Job{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := scanJSON(outline, &job.Outline); err != nil {
+		return models.Job{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := scanJSON(citations, &job.Citations); err != nil {
+		return models.Job{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if cacheHits.Valid && cacheMisses.Valid {
+		job.Cache = &models.CacheStats{Hits: int(cacheHits.Int64), Misses: int(cacheMisses.Int64)}
+	}
+	return job, nil
+}
+
+// Claim moves a pending job to running and returns it together with its options and source.
+// A job that was deleted or already picked up yields ErrJobNotFound.
+func (r *jobsRepo) Claim(ctx context.Context, jobID string) (models.Job, error) {
+	const op = "repository.ClaimJob"
+
+	const query = `
+		UPDATE jobs
+		SET status = $1, updated_at = NOW()
+		WHERE id = $2 AND status = $3
+		RETURNING id, COALESCE(user_id, 0), mode, options, filename, source
+	`
+	job := models.Job{Status: models.JobRunning}
+	var options []byte
+	err := r.db.QueryRowContext(ctx, query, models.JobRunning, jobID, models.JobPending).Scan(
+		&job.ID, &job.UserID, &job.Mode, &options, &job.Filename, &job.Source,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Job{}, fmt.Errorf("%s: %w", op, ErrJobNotFound)
+		}
+		return models.Job{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := json.Unmarshal(options, &job.Options); err != nil {
+		return models.Job{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return job, nil
+}
+
+func (r *jobsRepo) UpdateProgress(ctx context.Context, jobID string, done, total int) error {
+	const op = "repository.UpdateJobProgress"
+
+	const query = `
+		UPDATE jobs
+		SET chunks_done = $1, chunks_total = $2, updated_at = NOW()
+		WHERE id = $3
+	`
+	_, err := r.db.ExecContext(ctx, query, done, total, jobID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// Complete stores the summary text, structure, outline, citations and source language, its ID
+// when the summary was saved to the history, and the cache statistics when the cache is enabled.
+func (r *jobsRepo) Complete(ctx context.Context, jobID string, summary models.Summary) error {
+	const op = "repository.CompleteJob"
+
+	const query = `
+		UPDATE jobs
+		SET status = $1, result = $2, structured = $3, outline = $4, citations = $5, source_language = $6,
+		    summary_id = NULLIF($7, 0), cache_hits = $8, cache_misses = $9, source = '', updated_at = NOW()
+		WHERE id = $10
+	`
+	var cacheHits, cacheMisses sql.NullInt64
+	if summary.Cache != nil {
+		cacheHits = sql.NullInt64{Int64: int64(summary.Cache.Hits), Valid: true}
+		cacheMisses = sql.NullInt64{Int64: int64(summary.Cache.Misses), Valid: true}
+	}
+	structured, err := nullJSON(summary.Structured)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	outline, err := nullJSON(summary.Outline)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	citations, err := nullJSON(summary.Citations)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	_, err = r.db.ExecContext(ctx, query, models.JobDone, summary.Text, structured, outline, citations,
+		summary.SourceLanguage, summary.ID, cacheHits, cacheMisses, jobID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (r *jobsRepo) Fail(ctx context.Context, jobID string, message string) error {
+	const op = "repository.FailJob"
+
+	const query = `
+		UPDATE jobs
+		SET status = $1, error = $2, source = '', updated_at = NOW()
+		WHERE id = $3
+	`
+	_, err := r.db.ExecContext(ctx, query, models.JobFailed, message, jobID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (r *jobsRepo) Delete(ctx context.Context, jobID string, userID int) error {
+	const op = "repository.DeleteJob"
+
+	const query = `
+		DELETE FROM jobs
+		WHERE id = $1 AND user_id IS NOT DISTINCT FROM NULLIF($2, 0)
+	`
+	res, err := r.db.ExecContext(ctx, query, jobID, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s: %w", op, ErrJobNotFound)
+	}
+	return nil
+}
+
+// ResetUnfinished puts the pending and running jobs that have not been updated for staleAfter
+// back into the pending state and returns them. A running job is updated when it is claimed and
+// is cancelled once the job timeout has passed, so with staleAfter of at least that timeout
+// only running jobs whose worker is gone are taken. A pending job is updated when it is queued,
+// so one is only taken after waiting that long; Claim lets a single instance run it. The
+// returned jobs count as updated, so they are not returned again before staleAfter passes once more.
+func (r *jobsRepo) ResetUnfinished(ctx context.Context, staleAfter time.Duration) ([]string, error) {
+	const op = "repository.ResetUnfinishedJobs"
+
+	const query = `
+		UPDATE jobs
+		SET status = $1, chunks_done = 0, updated_at = NOW()
+		WHERE status IN ($1, $2) AND updated_at < NOW() - $3 * INTERVAL '1 microsecond'
+		RETURNING id
+	`
+	rows, err := r.db.QueryContext(ctx, query, models.JobPending, models.JobRunning, staleAfter.Microseconds())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return ids, nil
+}
+
+// Release puts a job this instance gave up on shutdown back into the pending state, marked as
+// stale so that the next ResetUnfinished of any instance takes it at once.
+func (r *jobsRepo) Release(ctx context.Context, jobID string) error {
+	const op = "repository.ReleaseJob"
+
+	const query = `
+		UPDATE jobs
+		SET status = $1, chunks_done = 0, updated_at = '-infinity'
+		WHERE id = $2 AND status IN ($1, $3)
+	`
+	_, err := r.db.ExecContext(ctx, query, models.JobPending, jobID, models.JobRunning)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
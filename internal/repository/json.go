@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// nullJSON encodes v for a nullable JSONB column. A nil pointer or slice is stored as NULL.
+func nullJSON(v any) ([]byte, error) {
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Map:
+		if rv.IsNil() {
+			return nil, nil
+		}
+	}
+	return json.Marshal(v)
+}
+
+// scanJSON decodes a nullable JSONB column read into data. NULL leaves v unchanged.
+func scanJSON(data []byte, v any) error {
+	if data == nil {
+		return nil
+	}
+	return json.Unmarshal(data, v)
+}
@@ -2,16 +2,42 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
 	"todoai/internal/models"
+	"todoai/pkg/cursor"
 )
 
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+	// ErrVersionConflict means the list was changed since the version the caller has.
+	ErrVersionConflict = errors.New("list version conflict")
+)
+
+// listCursorTime is the layout of the dates in a list cursor.
+const listCursorTime = "2006-01-02T15:04:05.999999"
+
+// previewLength is the number of characters of the body returned as a preview.
+const previewLength = 200
+
+// listSortColumns are the columns lists are sorted by.
+var listSortColumns = map[string]string{
+	models.ListSortCreated: "created_at",
+	models.ListSortUpdated: "updated_at",
+	models.ListSortTitle:   "title",
+}
+
 type Lists interface {
 	Create(ctx context.Context, list *models.List, userID int) error
-	UpdateBody(ctx context.Context, listID int, body string, userID int) error
+	// Update applies the update to the list and returns the updated list. A non-zero version
+	// must match the current version of the list.
+	Update(ctx context.Context, listID int, update models.ListUpdate, version int, userID int) (models.List, error)
 	Delete(ctx context.Context, listID int, userID int) error
 	GetByID(ctx context.Context, listID int, userID int) (models.List, error)
-	Get(ctx context.Context, userID int) ([]models.List, error)
+	// Get returns a page of the user's lists. The query must have its sort, order and limit set.
+	Get(ctx context.Context, query models.ListQuery, userID int) (models.ListPage, error)
 }
 
 type listsRepo struct {
@@ -22,14 +48,25 @@ func NewListsRepo(db Querier) *listsRepo {
 	return &listsRepo{db: db}
 }
 
+// listPosition is the position of the last list of a page encoded in the cursor. The sort and
+// order are kept so that a cursor is not used with another query.
+type listPosition struct {
+	Sort  string `json:"s"`
+	Order string `json:"o"`
+	Value string `json:"v"`
+	ID    int    `json:"id"`
+}
+
+// Create stores the list and fills in its ID, timestamps and version.
 func (l *listsRepo) Create(ctx context.Context, list *models.List, userID int) error {
 	const op = "repository.CreateList"
 
 	const query = `
 		INSERT INTO lists (title, body, user_id)
 		VALUES ($1, $2, $3)
+		RETURNING id, created_at, updated_at, version
 	`
-	_, err := l.db.ExecContext(ctx, query, list.Title, list.Body, userID)
+	err := l.db.QueryRowContext(ctx, query, list.Title, list.Body, userID).Scan(&list.ID, &list.CreatedAt, &list.UpdatedAt, &list.Version)
 	if err != nil {
 
 		return fmt.Errorf("%s: %w", op, err)
@@ -41,55 +78,151 @@ func (l *listsRepo) GetByID(ctx context.Context, listID int, userID int) (models
 	const op = "repository.GetListByID"
 
 	const query = `
-		SELECT id, title, body FROM lists
+		SELECT id, title, body, created_at, updated_at, version FROM lists
 		WHERE id = $1 AND user_id = $2
 	`
 	rows := l.db.QueryRowContext(ctx, query, listID, userID)
 
 	var list models.List
-	if err := rows.Scan(&list.ID, &list.Title, &list.Body); err != nil {
+	if err := rows.Scan(&list.ID, &list.Title, &list.Body, &list.CreatedAt, &list.UpdatedAt, &list.Version); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.List{}, fmt.Errorf("%s: %w", op, ErrListNotFound)
+		}
 		return models.List{}, fmt.Errorf("%s: %w", op, err)
 	}
 	return list, nil
 }
 
-func (l *listsRepo) Get(ctx context.Context, userID int) ([]models.List, error) {
+// Get pages through the lists with a keyset on the sort column and the ID, so that lists
+// created while the user pages do not shift the pages.
+func (l *listsRepo) Get(ctx context.Context, query models.ListQuery, userID int) (models.ListPage, error) {
 	const op = "repository.GetLists"
 
-	const query = `
-		SELECT id, title, body FROM lists
-		WHERE user_id = $1
-	`
-	rows, err := l.db.QueryContext(ctx, query, userID)
+	column, ok := listSortColumns[query.Sort]
+	if !ok {
+		return models.ListPage{}, fmt.Errorf("%s: unknown sort %q", op, query.Sort)
+	}
+	direction, compare := "ASC", ">"
+	if query.Order == "desc" {
+		direction, compare = "DESC", "<"
+	}
+	body := "body"
+	if query.Preview {
+		body = fmt.Sprintf("LEFT(body, %d)", previewLength)
+	}
+
+	args := []any{userID}
+	where := "user_id = $1"
+	if query.Title != "" {
+		args = append(args, "%"+escapeLike(query.Title)+"%")
+		where += fmt.Sprintf(" AND title ILIKE $%d", len(args))
+	}
+	if query.Cursor != "" {
+		position, err := decodeListCursor(query)
+		if err != nil {
+			return models.ListPage{}, fmt.Errorf("%s: %w", op, err)
+		}
+		cast := "timestamp"
+		if query.Sort == models.ListSortTitle {
+			cast = "text"
+		}
+		args = append(args, position.Value, position.ID)
+		where += fmt.Sprintf(" AND (%s, id) %s ($%d::%s, $%d)", column, compare, len(args)-1, cast, len(args))
+	}
+	// One more list than asked for tells whether there is a next page.
+	args = append(args, query.Limit+1)
+
+	sqlQuery := fmt.Sprintf(`
+		SELECT id, title, %s, created_at, updated_at, version FROM lists
+		WHERE %s
+		ORDER BY %s %s, id %s
+		LIMIT $%d
+	`, body, where, column, direction, direction, len(args))
+	rows, err := l.db.QueryContext(ctx, sqlQuery, args...)
 	if err != nil {
-		return nil, fmt.Errorf("%s: %w", op, err)
+		return models.ListPage{}, fmt.Errorf("%s: %w", op, err)
 	}
 	defer rows.Close()
 
-	var lists []models.List
+	page := models.ListPage{Lists: []models.List{}}
 	for rows.Next() {
 		var list models.List
-		if err := rows.Scan(&list.ID, &list.Title, &list.Body); err != nil {
-			return nil, fmt.Errorf("%s: %w", op, err)
+		if err := rows.Scan(&list.ID, &list.Title, &list.Body, &list.CreatedAt, &list.UpdatedAt, &list.Version); err != nil {
+			return models.ListPage{}, fmt.Errorf("%s: %w", op, err)
 		}
-		lists = append(lists, list)
+		page.Lists = append(page.Lists, list)
+	}
+	if err := rows.Err(); err != nil {
+		return models.ListPage{}, fmt.Errorf("%s: %w", op, err)
 	}
-	return lists, nil
+
+	if len(page.Lists) > query.Limit {
+		page.Lists = page.Lists[:query.Limit]
+		last := page.Lists[len(page.Lists)-1]
+		position := listPosition{Sort: query.Sort, Order: query.Order, ID: last.ID}
+		// The dates are TIMESTAMP columns without a time zone, which pgx scans as UTC. Formatted
+		// with the offset dropped, they are cast back to the very same timestamp, microseconds
+		// included, whatever the time zone of the session.
+		switch query.Sort {
+		case models.ListSortCreated:
+			position.Value = last.CreatedAt.Format(listCursorTime)
+		case models.ListSortUpdated:
+			position.Value = last.UpdatedAt.Format(listCursorTime)
+		case models.ListSortTitle:
+			position.Value = last.Title
+		}
+		if page.NextCursor, err = cursor.Encode(position); err != nil {
+			return models.ListPage{}, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	return page, nil
+}
+
+// decodeListCursor decodes the cursor of the query and checks that it was made for the same
+// sort and order.
+func decodeListCursor(query models.ListQuery) (listPosition, error) {
+	var position listPosition
+	if err := cursor.Decode(query.Cursor, &position); err != nil || position.Sort != query.Sort || position.Order != query.Order {
+		return listPosition{}, ErrInvalidCursor
+	}
+	return position, nil
 }
 
-func (l *listsRepo) UpdateBody(ctx context.Context, listID int, body string, userID int) error {
-	const op = "repository.UpdateListBody"
+// escapeLike escapes the wildcards of a LIKE pattern.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+func (l *listsRepo) Update(ctx context.Context, listID int, update models.ListUpdate, version int, userID int) (models.List, error) {
+	const op = "repository.UpdateList"
 
 	const query = `
 		UPDATE lists
-		SET body = $1
-		WHERE id = $2 AND user_id = $3
+		SET title = COALESCE($3, title), body = COALESCE($4, body),
+		    version = version + 1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND user_id = $2 AND ($5 = 0 OR version = $5)
+		RETURNING id, title, body, created_at, updated_at, version
 	`
-	_, err := l.db.ExecContext(ctx, query, body, listID, userID)
-	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+	var list models.List
+	err := l.db.QueryRowContext(ctx, query, listID, userID, update.Title, update.Body, version).
+		Scan(&list.ID, &list.Title, &list.Body, &list.CreatedAt, &list.UpdatedAt, &list.Version)
+	if err == nil {
+		return list, nil
 	}
-	return nil
+	if !errors.Is(err, sql.ErrNoRows) {
+		return models.List{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Nothing was updated: either there is no such list or its version has moved on.
+	const existsQuery = `SELECT EXISTS (SELECT 1 FROM lists WHERE id = $1 AND user_id = $2)`
+	var exists bool
+	if err := l.db.QueryRowContext(ctx, existsQuery, listID, userID).Scan(&exists); err != nil {
+		return models.List{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if !exists {
+		return models.List{}, fmt.Errorf("%s: %w", op, ErrListNotFound)
+	}
+	return models.List{}, fmt.Errorf("%s: %w", op, ErrVersionConflict)
 }
 
 func (l *listsRepo) Delete(ctx context.Context, listID int, userID int) error {
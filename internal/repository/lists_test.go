@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"testing"
+	"time"
+	"todoai/internal/models"
+	"todoai/pkg/cursor"
+
+	"github.com/stretchr/testify/require"
+)
+
+// scriptedRows answers a query with the given columns and rows.
+type scriptedRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+// scriptedDB is a database/sql driver that answers every query with the rows of the first
+// script key contained in it.
+type scriptedDB map[string]scriptedRows
+
+func (s scriptedDB) Connect(ctx context.Context) (driver.Conn, error) { return scriptedConn{s}, nil }
+func (s scriptedDB) Driver() driver.Driver                            { return nil }
+
+type scriptedConn struct{ db scriptedDB }
+
+func (c scriptedConn) Prepare(query string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c scriptedConn) Close() error                              { return nil }
+func (c scriptedConn) Begin() (driver.Tx, error)                 { return nil, driver.ErrSkip }
+
+func (c scriptedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	for key, rows := range c.db {
+		if strings.Contains(query, key) {
+			return &scriptedCursor{rows: rows}, nil
+		}
+	}
+	return &scriptedCursor{}, nil
+}
+
+type scriptedCursor struct {
+	rows scriptedRows
+	next int
+}
+
+func (r *scriptedCursor) Columns() []string { return r.rows.columns }
+func (r *scriptedCursor) Close() error      { return nil }
+
+func (r *scriptedCursor) Next(dest []driver.Value) error {
+	if r.next == len(r.rows.values) {
+		return io.EOF
+	}
+	copy(dest, r.rows.values[r.next])
+	r.next++
+	return nil
+}
+
+func TestListsRepo_Update(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	title := "groceries"
+	updated := scriptedRows{
+		columns: []string{"id", "title", "body", "created_at", "updated_at", "version"},
+		values:  [][]driver.Value{{int64(1), title, "milk", now, now, int64(3)}},
+	}
+	exists := func(ok bool) scriptedRows {
+		return scriptedRows{columns: []string{"exists"}, values: [][]driver.Value{{ok}}}
+	}
+
+	testTable := []struct {
+		name          string
+		script        scriptedDB
+		expectedList  models.List
+		expectedError error
+	}{
+		{
+			name:         "updated",
+			script:       scriptedDB{"UPDATE lists": updated},
+			expectedList: models.List{ID: 1, Title: title, Body: "milk", CreatedAt: now, UpdatedAt: now, Version: 3},
+		},
+		{
+			name:          "version conflict",
+			script:        scriptedDB{"SELECT EXISTS": exists(true)},
+			expectedError: ErrVersionConflict,
+		},
+		{
+			name:          "not found",
+			script:        scriptedDB{"SELECT EXISTS": exists(false)},
+			expectedError: ErrListNotFound,
+		},
+	}
+
+	for _, tt := range testTable {
+		t.Run(tt.name, func(t *testing.T) {
+			db := sql.OpenDB(tt.script)
+			defer db.Close()
+
+			list, err := NewListsRepo(db).Update(context.Background(), 1, models.ListUpdate{Title: &title}, 2, 1)
+			require.ErrorIs(t, err, tt.expectedError)
+			require.Equal(t, tt.expectedList, list)
+		})
+	}
+}
+
+func TestEscapeLike(t *testing.T) {
+	testTable := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "plain", input: "groceries", expected: "groceries"},
+		{name: "percent", input: "100%", expected: `100\%`},
+		{name: "underscore", input: "to_do", expected: `to\_do`},
+		{name: "backslash", input: `a\b`, expected: `a\\b`},
+		{name: "escaped wildcard", input: `\%`, expected: `\\\%`},
+	}
+
+	for _, tt := range testTable {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, escapeLike(tt.input))
+		})
+	}
+}
+
+func TestDecodeListCursor(t *testing.T) {
+	position := listPosition{Sort: models.ListSortCreated, Order: "desc", Value: "2025-01-02T03:04:05.123456", ID: 7}
+	encoded, err := cursor.Encode(position)
+	require.NoError(t, err)
+
+	testTable := []struct {
+		name          string
+		query         models.ListQuery
+		expectedError error
+	}{
+		{
+			name:  "same query",
+			query: models.ListQuery{Sort: models.ListSortCreated, Order: "desc", Cursor: encoded},
+		},
+		{
+			name:          "other sort",
+			query:         models.ListQuery{Sort: models.ListSortTitle, Order: "desc", Cursor: encoded},
+			expectedError: ErrInvalidCursor,
+		},
+		{
+			name:          "other order",
+			query:         models.ListQuery{Sort: models.ListSortCreated, Order: "asc", Cursor: encoded},
+			expectedError: ErrInvalidCursor,
+		},
+		{
+			name:          "malformed",
+			query:         models.ListQuery{Sort: models.ListSortCreated, Order: "desc", Cursor: "not a cursor"},
+			expectedError: ErrInvalidCursor,
+		},
+	}
+
+	for _, tt := range testTable {
+		t.Run(tt.name, func(t *testing.T) {
+			decoded, err := decodeListCursor(tt.query)
+			if tt.expectedError != nil {
+				require.ErrorIs(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, position, decoded)
+		})
+	}
+}
+
+func TestListCursorTime(t *testing.T) {
+	created := time.Date(2025, 1, 2, 3, 4, 5, 123456000, time.UTC)
+	value := created.Format(listCursorTime)
+	require.Equal(t, "2025-01-02T03:04:05.123456", value)
+
+	parsed, err := time.Parse(listCursorTime, value)
+	require.NoError(t, err)
+	require.True(t, created.Equal(parsed))
+}
@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"todoai/internal/models"
+)
+
+var (
+	ErrModeNotFound = errors.New("mode not found")
+	ErrModeExists   = errors.New("mode exists")
+)
+
+type Modes interface {
+	Create(ctx context.Context, mode *models.Mode, userID int) error
+	GetByName(ctx context.Context, name string, userID int) (models.Mode, error)
+	Get(ctx context.Context, userID int) ([]models.Mode, error)
+	Update(ctx context.Context, mode *models.Mode, userID int) error
+	Delete(ctx context.Context, modeID int, userID int) error
+}
+
+type modesRepo struct {
+	db Querier
+}
+
+func NewModesRepo(db Querier) *modesRepo {
+	return &modesRepo{db: db}
+}
+
+func (r *modesRepo) Create(ctx context.Context, mode *models.Mode, userID int) error {
+	const op = "repository.CreateMode"
+
+	const query = `
+		INSERT INTO modes (user_id, name, compressor_prompt, formatter_prompt, compress_temp, format_temp)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`
+	err := r.db.QueryRowContext(ctx, query,
+		userID, mode.Name, mode.CompressorPrompt, mode.FormatterPrompt, mode.CompressTemp, mode.FormatTemp,
+	).Scan(&mode.ID)
+	if err != nil {
+		if strings.Contains(err.Error(), userExistsError) {
+			return fmt.Errorf("%s: %w", op, ErrModeExists)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (r *modesRepo) GetByName(ctx context.Context, name string, userID int) (models.Mode, error) {
+	const op = "repository.GetModeByName"
+
+	const query = `
+		SELECT id, name, compressor_prompt, formatter_prompt, compress_temp, format_temp
+		FROM modes
+		WHERE name = $1 AND user_id = $2
+	`
+	mode, err := scanMode(r.db.QueryRowContext(ctx, query, name, userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Mode{}, fmt.Errorf("%s: %w", op, ErrModeNotFound)
+		}
+		return models.Mode{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return mode, nil
+}
+
+func (r *modesRepo) Get(ctx context.Context, userID int) ([]models.Mode, error) {
+	const op = "repository.GetModes"
+
+	const query = `
+		SELECT id, name, compressor_prompt, formatter_prompt, compress_temp, format_temp
+		FROM modes
+		WHERE user_id = $1
+		ORDER BY name
+	`
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var modes []models.Mode
+	for rows.Next() {
+		mode, err := scanMode(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		modes = append(modes, mode)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return modes, nil
+}
+
+func (r *modesRepo) Update(ctx context.Context, mode *models.Mode, userID int) error {
+	const op = "repository.UpdateMode"
+
+	const query = `
+		UPDATE modes
+		SET name = $1, compressor_prompt = $2, formatter_prompt = $3, compress_temp = $4, format_temp = $5
+		WHERE id = $6 AND user_id = $7
+	`
+	res, err := r.db.ExecContext(ctx, query,
+		mode.Name, mode.CompressorPrompt, mode.FormatterPrompt, mode.CompressTemp, mode.FormatTemp, mode.ID, userID,
+	)
+	if err != nil {
+		if strings.Contains(err.Error(), userExistsError) {
+			return fmt.Errorf("%s: %w", op, ErrModeExists)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s: %w", op, ErrModeNotFound)
+	}
+	return nil
+}
+
+func (r *modesRepo) Delete(ctx context.Context, modeID int, userID int) error {
+	const op = "repository.DeleteMode"
+
+	const query = `
+		DELETE FROM modes
+		WHERE id = $1 AND user_id = $2
+	`
+	res, err := r.db.ExecContext(ctx, query, modeID, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s: %w", op, ErrModeNotFound)
+	}
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanMode(row rowScanner) (models.Mode, error) {
+	var mode models.Mode
+	var compressTemp, formatTemp sql.NullFloat64
+	if err := row.Scan(&mode.ID, &mode.Name, &mode.CompressorPrompt, &mode.FormatterPrompt, &compressTemp, &formatTemp); err != nil {
+		return models.Mode{}, err
+	}
+	if compressTemp.Valid {
+		t := float32(compressTemp.Float64)
+		mode.CompressTemp = &t
+	}
+	if formatTemp.Valid {
+		t := float32(formatTemp.Float64)
+		mode.FormatTemp = &t
+	}
+	return mode, nil
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+	"todoai/internal/models"
+)
+
+type Search interface {
+	// Search returns the user's lists and summaries matching the query, best match first.
+	Search(ctx context.Context, query string, limit int, userID int) ([]models.SearchResult, error)
+}
+
+type searchRepo struct {
+	db Querier
+}
+
+func NewSearchRepo(db Querier) *searchRepo {
+	return &searchRepo{db: db}
+}
+
+const (
+	// markStart and markStop delimit the matches in ts_headline output until the snippet is
+	// escaped; they are removed from the text beforehand so that they cannot come from it.
+	markStart = "\x01"
+	markStop  = "\x02"
+
+	headlineOptions = "StartSel=" + markStart + ", StopSel=" + markStop + ", MaxFragments=2, MaxWords=30, MinWords=10"
+)
+
+// Search matches the query, written as in a web search engine, against the search columns of
+// lists and summaries. The snippets are made only for the returned rows since ts_headline
+// parses the whole text.
+func (r *searchRepo) Search(ctx context.Context, query string, limit int, userID int) ([]models.SearchResult, error) {
+	const op = "repository.Search"
+
+	const sqlQuery = `
+		WITH q AS (
+			SELECT websearch_to_tsquery('russian', $2) AS query
+		), hits AS (
+			SELECT 'list' AS kind, l.id, l.title, l.body AS text,
+			       l.created_at::timestamptz AS created_at, ts_rank(l.search, q.query) AS rank
+			FROM lists l, q
+			WHERE l.user_id = $1 AND l.search @@ q.query
+			UNION ALL
+			SELECT 'summary', s.id, s.filename, s.result,
+			       s.created_at, ts_rank(s.search, q.query)
+			FROM summaries s, q
+			WHERE s.user_id = $1 AND s.search @@ q.query
+			ORDER BY rank DESC, created_at DESC
+			LIMIT $3
+		)
+		SELECT h.kind, h.id, h.title,
+		       ts_headline('russian', translate(h.text, $4, ''), q.query, $5),
+		       h.rank, h.created_at
+		FROM hits h, q
+		ORDER BY h.rank DESC, h.created_at DESC
+	`
+	rows, err := r.db.QueryContext(ctx, sqlQuery, userID, query, limit, markStart+markStop, headlineOptions)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	results := []models.SearchResult{}
+	for rows.Next() {
+		var result models.SearchResult
+		if err := rows.Scan(&result.Kind, &result.ID, &result.Title, &result.Snippet, &result.Rank, &result.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		result.Snippet = markSnippet(result.Snippet)
+		results = append(results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return results, nil
+}
+
+// markSnippet escapes the user text of a ts_headline snippet as HTML and only then wraps the
+// matches in <mark> tags, so that the snippet is safe to render.
+func markSnippet(snippet string) string {
+	return strings.NewReplacer(markStart, "<mark>", markStop, "</mark>").Replace(html.EscapeString(snippet))
+}
@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarkSnippet(t *testing.T) {
+	testTable := []struct {
+		name     string
+		snippet  string
+		expected string
+	}{
+		{
+			name:     "matches",
+			snippet:  "the " + markStart + "contract" + markStop + " is void",
+			expected: "the <mark>contract</mark> is void",
+		},
+		{
+			name:     "markup in the text",
+			snippet:  `<script>alert("x")</script> & ` + markStart + "договор" + markStop,
+			expected: "&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt; &amp; <mark>договор</mark>",
+		},
+		{
+			name:     "mark tags in the text",
+			snippet:  "<mark>fake</mark>",
+			expected: "&lt;mark&gt;fake&lt;/mark&gt;",
+		},
+	}
+
+	for _, tt := range testTable {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, markSnippet(tt.snippet))
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"todoai/internal/models"
+)
+
+var ErrSummaryNotFound = errors.New("summary not found")
+
+type Summaries interface {
+	Create(ctx context.Context, summary *models.Summary, userID int) error
+	GetByID(ctx context.Context, summaryID int, userID int) (models.Summary, error)
+	Get(ctx context.Context, limit, offset int, userID int) ([]models.Summary, int, error)
+	Delete(ctx context.Context, summaryID int, userID int) error
+}
+
+type summariesRepo struct {
+	db Querier
+}
+
+func NewSummariesRepo(db Querier) *summariesRepo {
+	return &summariesRepo{db: db}
+}
+
+// Create stores the summary and fills in its ID and creation time.
+func (r *summariesRepo) Create(ctx context.Context, summary *models.Summary, userID int) error {
+	const op = "repository.CreateSummary"
+
+	const query = `
+		INSERT INTO summaries (user_id, filename, mode, text_hash, result, format, structured, outline, citations,
+		                       source_language, target_language, model, duration_ms)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING id, created_at
+	`
+	structured, err := nullJSON(summary.Structured)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	outline, err := nullJSON(summary.Outline)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	citations, err := nullJSON(summary.Citations)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	err = r.db.QueryRowContext(ctx, query,
+		userID, summary.Filename, summary.Mode, summary.TextHash, summary.Text, summary.Format, structured, outline, citations,
+		summary.SourceLanguage, summary.TargetLanguage, summary.Model, summary.DurationMs,
+	).Scan(&summary.ID, &summary.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (r *summariesRepo) GetByID(ctx context.Context, summaryID int, userID int) (models.Summary, error) {
+	const op = "repository.GetSummaryByID"
+
+	const query = `
+		SELECT id, filename, mode, text_hash, result, format, structured, outline, citations,
+		       source_language, target_language, model, duration_ms, created_at
+		FROM summaries
+		WHERE id = $1 AND user_id = $2
+	`
+	summary, err := scanSummary(r.db.QueryRowContext(ctx, query, summaryID, userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Summary{}, fmt.Errorf("%s: %w", op, ErrSummaryNotFound)
+		}
+		return models.Summary{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return summary, nil
+}
+
+// Get returns a page of the user's summaries, newest first, and the total number of them.
+func (r *summariesRepo) Get(ctx context.Context, limit, offset int, userID int) ([]models.Summary, int, error) {
+	const op = "repository.GetSummaries"
+
+	const query = `
+		SELECT id, filename, mode, text_hash, result, format, structured, outline, citations,
+		       source_language, target_language, model, duration_ms, created_at,
+		       COUNT(*) OVER ()
+		FROM summaries
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2 OFFSET $3
+	`
+	rows, err := r.db.QueryContext(ctx, query, userID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	summaries := []models.Summary{}
+	total := 0
+	for rows.Next() {
+		summary, err := scanSummary(rows, &total)
+		if err != nil {
+			return nil, 0, fmt.Errorf("%s: %w", op, err)
+		}
+		summaries = append(summaries, summary)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// A page past the end has no rows to carry the total.
+	if len(summaries) == 0 && offset > 0 {
+		const countQuery = `SELECT COUNT(*) FROM summaries WHERE user_id = $1`
+		if err := r.db.QueryRowContext(ctx, countQuery, userID).Scan(&total); err != nil {
+			return nil, 0, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	return summaries, total, nil
+}
+
+// scanSummary reads the columns selected by GetByID followed by extra.
+func scanSummary(row rowScanner, extra ...any) (models.Summary, error) {
+	var summary models.Summary
+	var structured, outline, citations []byte
+	dest := append([]any{
+		&summary.ID, &summary.Filename, &summary.Mode, &summary.TextHash, &summary.Text, &summary.Format,
+		&structured, &outline, &citations, &summary.SourceLanguage, &summary.TargetLanguage,
+		&summary.Model, &summary.DurationMs, &summary.CreatedAt,
+	}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return models.Summary{}, err
+	}
+	if err := scanJSON(structured, &summary.Structured); err != nil {
+		return models.Summary{}, err
+	}
+	if err := scanJSON(outline, &summary.Outline); err != nil {
+		return models.Summary{}, err
+	}
+	if err := scanJSON(citations, &summary.Citations); err != nil {
+		return models.Summary{}, err
+	}
+	return summary, nil
+}
+
+func (r *summariesRepo) Delete(ctx context.Context, summaryID int, userID int) error {
+	const op = "repository.DeleteSummary"
+
+	const query = `
+		DELETE FROM summaries
+		WHERE id = $1 AND user_id = $2
+	`
+	res, err := r.db.ExecContext(ctx, query, summaryID, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s: %w", op, ErrSummaryNotFound)
+	}
+	return nil
+}
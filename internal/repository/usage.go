@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+	"todoai/internal/models"
+)
+
+type Usage interface {
+	Record(ctx context.Context, usage models.TokenUsage, userID int) error
+	Sum(ctx context.Context, since time.Time, userID int) (models.TokenUsage, error)
+}
+
+type usageRepo struct {
+	db Querier
+}
+
+func NewUsageRepo(db Querier) *usageRepo {
+	return &usageRepo{db: db}
+}
+
+// Record stores the tokens of one model call. A zero userID records anonymous usage.
+func (r *usageRepo) Record(ctx context.Context, usage models.TokenUsage, userID int) error {
+	const op = "repository.RecordUsage"
+
+	const query = `
+		INSERT INTO ai_usage (user_id, prompt_tokens, output_tokens)
+		VALUES (NULLIF($1, 0), $2, $3)
+	`
+	_, err := r.db.ExecContext(ctx, query, userID, usage.PromptTokens, usage.OutputTokens)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// Sum adds up the tokens spent since the given time. A zero userID sums all anonymous usage.
+func (r *usageRepo) Sum(ctx context.Context, since time.Time, userID int) (models.TokenUsage, error) {
+	const op = "repository.SumUsage"
+
+	// The user and the anonymous callers are summed by separate queries, so that each one
+	// is served by its own index.
+	const query = `
+		SELECT COALESCE(SUM(prompt_tokens), 0), COALESCE(SUM(output_tokens), 0)
+		FROM ai_usage
+		WHERE user_id = $1 AND created_at >= $2
+	`
+	const anonymousQuery = `
+		SELECT COALESCE(SUM(prompt_tokens), 0), COALESCE(SUM(output_tokens), 0)
+		FROM ai_usage
+		WHERE user_id IS NULL AND created_at >= $1
+	`
+	var row *sql.Row
+	if userID == 0 {
+		row = r.db.QueryRowContext(ctx, anonymousQuery, since)
+	} else {
+		row = r.db.QueryRowContext(ctx, query, userID, since)
+	}
+	var usage models.TokenUsage
+	if err := row.Scan(&usage.PromptTokens, &usage.OutputTokens); err != nil {
+		return models.TokenUsage{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return usage, nil
+}
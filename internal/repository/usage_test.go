@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+	"time"
+	"todoai/internal/models"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsageRepo_Sum(t *testing.T) {
+	sums := func(prompt, output int64) scriptedRows {
+		return scriptedRows{columns: []string{"prompt", "output"}, values: [][]driver.Value{{prompt, output}}}
+	}
+	script := scriptedDB{
+		"user_id = $1":    sums(10, 20),
+		"user_id IS NULL": sums(1, 2),
+	}
+
+	testTable := []struct {
+		name     string
+		userID   int
+		expected models.TokenUsage
+	}{
+		{name: "user", userID: 7, expected: models.TokenUsage{PromptTokens: 10, OutputTokens: 20}},
+		{name: "anonymous", userID: 0, expected: models.TokenUsage{PromptTokens: 1, OutputTokens: 2}},
+	}
+
+	for _, tt := range testTable {
+		t.Run(tt.name, func(t *testing.T) {
+			db := sql.OpenDB(script)
+			defer db.Close()
+
+			usage, err := NewUsageRepo(db).Sum(context.Background(), time.Now(), tt.userID)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, usage)
+		})
+	}
+}
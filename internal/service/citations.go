@@ -0,0 +1,105 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"todoai/internal/models"
+	"todoai/pkg/reader"
+	"todoai/pkg/splitter"
+	"unicode/utf8"
+)
+
+var (
+	// citationTags matches the source tags at the end of a sentence: [C3], [C2][C5] or [C2, C5].
+	citationTags = regexp.MustCompile(`(?:[ \t]*\[C\d+(?:\s*[,;]\s*C?\d+)*\])+`)
+	chunkNumber  = regexp.MustCompile(`\d+`)
+)
+
+// sourceRanges describes the chunks of the document with character offsets and pages.
+func sourceRanges(doc reader.Document, chunks []splitter.Chunk) []models.SourceRange {
+	// Overlapping chunks start before the previous one ends, so the byte offsets are sorted
+	// first and the characters are counted in one pass over them.
+	offsets := make([]int, 0, 2*len(chunks))
+	for _, chunk := range chunks {
+		offsets = append(offsets, chunk.Start, chunk.End)
+	}
+	slices.Sort(offsets)
+	runeOffsets := make(map[int]int, len(offsets))
+	bytePos, runePos := 0, 0
+	for _, offset := range slices.Compact(offsets) {
+		runePos += utf8.RuneCountInString(doc.Text[bytePos:offset])
+		bytePos = offset
+		runeOffsets[offset] = runePos
+	}
+
+	sources := make([]models.SourceRange, len(chunks))
+	for i, chunk := range chunks {
+		sources[i] = models.SourceRange{
+			Chunk:     i + 1,
+			Start:     runeOffsets[chunk.Start],
+			End:       runeOffsets[chunk.End],
+			PageStart: doc.Page(chunk.Start),
+			PageEnd:   doc.Page(max(chunk.End-1, chunk.Start)),
+		}
+	}
+	return sources
+}
+
+// tagSentences ends every sentence of the summary of a chunk with the tag of the chunk,
+// so that later passes can carry it over to the sentences based on them.
+func tagSentences(text string, chunk int, language string) string {
+	sentences := splitter.Sentences(text, language)
+	if len(sentences) == 0 {
+		return text
+	}
+	var b strings.Builder
+	for i, sentence := range sentences {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		fmt.Fprintf(&b, "%s [C%d]", sentence.Text, chunk)
+	}
+	return b.String()
+}
+
+// extractCitations removes the source tags from the final text and links the sentence before
+// every group of tags to the chunks they name. Tags of chunks that do not exist are ignored.
+func extractCitations(text string, sources []models.SourceRange) (string, []models.Citation) {
+	var clean strings.Builder
+	var citations []models.Citation
+	prev := 0
+	for _, loc := range citationTags.FindAllStringIndex(text, -1) {
+		segment := text[prev:loc[0]]
+		clean.WriteString(segment)
+		prev = loc[1]
+
+		var cited []models.SourceRange
+		var seen []int
+		for _, match := range chunkNumber.FindAllString(text[loc[0]:loc[1]], -1) {
+			n, err := strconv.Atoi(match)
+			if err != nil || n < 1 || n > len(sources) || slices.Contains(seen, n) {
+				continue
+			}
+			seen = append(seen, n)
+			cited = append(cited, sources[n-1])
+		}
+		sentence := citedSentence(segment)
+		if sentence == "" || len(cited) == 0 {
+			continue
+		}
+		citations = append(citations, models.Citation{Sentence: sentence, Sources: cited})
+	}
+	clean.WriteString(text[prev:])
+	return clean.String(), citations
+}
+
+// citedSentence is the last line of the text before a group of tags without its list
+// or heading marker.
+func citedSentence(segment string) string {
+	lines := strings.Split(strings.TrimSpace(segment), "\n")
+	line := strings.TrimSpace(lines[len(lines)-1])
+	return strings.TrimSpace(strings.TrimLeft(line, "#>-*+ "))
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"testing"
+	"todoai/internal/models"
+	"todoai/pkg/reader"
+	"todoai/pkg/splitter"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSourceRanges(t *testing.T) {
+	doc := reader.Document{Text: "Первая страница. Second page.", Pages: []int{0, 31}}
+	chunks := []splitter.Chunk{{Start: 0, End: 30}, {Start: 31, End: 43}}
+
+	require.Equal(t, []models.SourceRange{
+		{Chunk: 1, Start: 0, End: 16, PageStart: 1, PageEnd: 1},
+		{Chunk: 2, Start: 17, End: 29, PageStart: 2, PageEnd: 2},
+	}, sourceRanges(doc, chunks))
+}
+
+func TestSourceRanges_Overlap(t *testing.T) {
+	doc := reader.Document{Text: "Первая страница. Second page.", Pages: []int{0, 31}}
+	chunks := []splitter.Chunk{{Start: 0, End: 30}, {Start: 17, End: 43}}
+
+	require.Equal(t, []models.SourceRange{
+		{Chunk: 1, Start: 0, End: 16, PageStart: 1, PageEnd: 1},
+		{Chunk: 2, Start: 9, End: 29, PageStart: 1, PageEnd: 2},
+	}, sourceRanges(doc, chunks))
+}
+
+func TestTagSentences(t *testing.T) {
+	require.Equal(t, "The court ruled. [C2] The claim was dismissed. [C2]",
+		tagSentences("The court ruled. The claim was dismissed.", 2, "en"))
+	require.Equal(t, "", tagSentences("", 1, "en"))
+}
+
+func TestExtractCitations(t *testing.T) {
+	sources := []models.SourceRange{
+		{Chunk: 1, Start: 0, End: 100, PageStart: 1, PageEnd: 1},
+		{Chunk: 2, Start: 90, End: 200, PageStart: 1, PageEnd: 2},
+	}
+
+	testTable := []struct {
+		name              string
+		text              string
+		expectedText      string
+		expectedCitations []models.Citation
+	}{
+		{
+			name:         "tags after sentences",
+			text:         "The contract is void. [C1] The claim is dismissed. [C1][C2]",
+			expectedText: "The contract is void. The claim is dismissed.",
+			expectedCitations: []models.Citation{
+				{Sentence: "The contract is void.", Sources: sources[:1]},
+				{Sentence: "The claim is dismissed.", Sources: sources},
+			},
+		},
+		{
+			name:         "tags before the full stop",
+			text:         "The contract is void [C2, C1].",
+			expectedText: "The contract is void.",
+			expectedCitations: []models.Citation{
+				{Sentence: "The contract is void", Sources: []models.SourceRange{sources[1], sources[0]}},
+			},
+		},
+		{
+			name:         "list items",
+			text:         "## Ruling\n\n- The contract is void. [C1]\n- Costs are awarded. [C2]",
+			expectedText: "## Ruling\n\n- The contract is void.\n- Costs are awarded.",
+			expectedCitations: []models.Citation{
+				{Sentence: "The contract is void.", Sources: sources[:1]},
+				{Sentence: "Costs are awarded.", Sources: sources[1:]},
+			},
+		},
+		{
+			name:         "unknown chunks are ignored",
+			text:         "The contract is void. [C7] Costs are awarded. [C1][C1]",
+			expectedText: "The contract is void. Costs are awarded.",
+			expectedCitations: []models.Citation{
+				{Sentence: "Costs are awarded.", Sources: sources[:1]},
+			},
+		},
+		{
+			name:         "no tags",
+			text:         "The contract is void.",
+			expectedText: "The contract is void.",
+		},
+	}
+
+	for _, tt := range testTable {
+		t.Run(tt.name, func(t *testing.T) {
+			text, citations := extractCitations(tt.text, sources)
+			require.Equal(t, tt.expectedText, text)
+			require.Equal(t, tt.expectedCitations, citations)
+		})
+	}
+}
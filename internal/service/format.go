@@ -0,0 +1,40 @@
+package service
+
+import (
+	"todoai/internal/models"
+	"todoai/pkg/markdown"
+)
+
+func formatOrDefault(format string) string {
+	if format == "" {
+		return models.FormatParagraphs
+	}
+	return format
+}
+
+// formatText cleans up the formatter answer for the requested format. The outline format also
+// returns the tree of the outline, and its text is rewritten from that tree.
+func formatText(format, text string) (string, []models.OutlineNode) {
+	switch format {
+	case models.FormatBullets:
+		return markdown.Bullets(markdown.Sanitize(text)), nil
+	case models.FormatMarkdown:
+		return markdown.Sanitize(text), nil
+	case models.FormatOutline:
+		outline := markdown.ParseOutline(markdown.Sanitize(text))
+		return markdown.RenderOutline(outline), outlineNodes(outline)
+	default:
+		return text, nil
+	}
+}
+
+func outlineNodes(outline []markdown.Node) []models.OutlineNode {
+	if len(outline) == 0 {
+		return nil
+	}
+	nodes := make([]models.OutlineNode, len(outline))
+	for i, node := range outline {
+		nodes[i] = models.OutlineNode{Title: node.Title, Children: outlineNodes(node.Children)}
+	}
+	return nodes
+}
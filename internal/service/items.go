@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"todoai/internal/models"
+	"todoai/internal/repository"
+)
+
+// ErrInvalidOrder means a new order of items does not name every item of the list exactly once.
+var ErrInvalidOrder = errors.New("the order must list every item of the list exactly once")
+
+type ItemsService interface {
+	Get(ctx context.Context, listID int, userID int) ([]models.Item, error)
+	// Add appends the item to the end of its list.
+	Add(ctx context.Context, item *models.Item, userID int) error
+	// Update replaces the text, due date, owner and priority of the item.
+	Update(ctx context.Context, item *models.Item, userID int) error
+	// SetDone completes or uncompletes the item.
+	SetDone(ctx context.Context, listID, itemID int, done bool, userID int) (models.Item, error)
+	// Reorder puts the items of the list in the order of itemIDs and returns them.
+	Reorder(ctx context.Context, listID int, itemIDs []int, userID int) ([]models.Item, error)
+	Delete(ctx context.Context, listID, itemID int, userID int) error
+}
+
+type itemsService struct {
+	log *slog.Logger
+	tm  *TransactionManager
+}
+
+func NewItemsService(tm *TransactionManager, log *slog.Logger) *itemsService {
+	return &itemsService{tm: tm, log: log}
+}
+
+func (s *itemsService) Get(ctx context.Context, listID int, userID int) ([]models.Item, error) {
+	const op = "service.itemsService.Get"
+	repo := s.tm.NewItemsRepo()
+	items, err := repo.GetByList(ctx, listID, userID)
+	if err != nil {
+		s.logError(op, err)
+		return nil, err
+	}
+	return items, nil
+}
+
+func (s *itemsService) Add(ctx context.Context, item *models.Item, userID int) error {
+	const op = "service.itemsService.Add"
+	repo := s.tm.NewItemsRepo()
+	if err := repo.Create(ctx, item, userID); err != nil {
+		s.logError(op, err)
+		return err
+	}
+	return nil
+}
+
+func (s *itemsService) Update(ctx context.Context, item *models.Item, userID int) error {
+	const op = "service.itemsService.Update"
+	repo := s.tm.NewItemsRepo()
+	if err := repo.Update(ctx, item, userID); err != nil {
+		s.logError(op, err)
+		return err
+	}
+	return nil
+}
+
+func (s *itemsService) SetDone(ctx context.Context, listID, itemID int, done bool, userID int) (models.Item, error) {
+	const op = "service.itemsService.SetDone"
+	repo := s.tm.NewItemsRepo()
+	item, err := repo.SetDone(ctx, listID, itemID, done, userID)
+	if err != nil {
+		s.logError(op, err)
+		return models.Item{}, err
+	}
+	return item, nil
+}
+
+func (s *itemsService) Reorder(ctx context.Context, listID int, itemIDs []int, userID int) ([]models.Item, error) {
+	const op = "service.itemsService.Reorder"
+	var items []models.Item
+	err := s.tm.WithTransaction(ctx, func(repos *TransactionalRepos) error {
+		current, err := repos.Items.GetByList(ctx, listID, userID)
+		if err != nil {
+			return err
+		}
+		if !sameItems(current, itemIDs) {
+			return ErrInvalidOrder
+		}
+		if err := repos.Items.Reorder(ctx, listID, itemIDs, userID); err != nil {
+			return err
+		}
+		items, err = repos.Items.GetByList(ctx, listID, userID)
+		return err
+	})
+	if err != nil {
+		if !errors.Is(err, ErrInvalidOrder) {
+			s.logError(op, err)
+		}
+		return nil, err
+	}
+	return items, nil
+}
+
+func (s *itemsService) Delete(ctx context.Context, listID, itemID int, userID int) error {
+	const op = "service.itemsService.Delete"
+	repo := s.tm.NewItemsRepo()
+	if err := repo.Delete(ctx, listID, itemID, userID); err != nil {
+		s.logError(op, err)
+		return err
+	}
+	return nil
+}
+
+// logError logs the failures that are not caused by the caller.
+func (s *itemsService) logError(op string, err error) {
+	if !errors.Is(err, repository.ErrListNotFound) && !errors.Is(err, repository.ErrItemNotFound) {
+		s.log.Error(op, "error", err)
+	}
+}
+
+// sameItems reports whether ids names every item exactly once.
+func sameItems(items []models.Item, ids []int) bool {
+	if len(items) != len(ids) {
+		return false
+	}
+	sorted := slices.Clone(ids)
+	slices.Sort(sorted)
+	current := make([]int, len(items))
+	for i, item := range items {
+		current[i] = item.ID
+	}
+	slices.Sort(current)
+	return slices.Equal(sorted, current)
+}
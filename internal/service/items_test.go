@@ -0,0 +1,30 @@
+package service
+
+import (
+	"testing"
+	"todoai/internal/models"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSameItems(t *testing.T) {
+	items := []models.Item{{ID: 1}, {ID: 2}, {ID: 3}}
+
+	testTable := []struct {
+		name     string
+		ids      []int
+		expected bool
+	}{
+		{name: "new order", ids: []int{3, 1, 2}, expected: true},
+		{name: "same order", ids: []int{1, 2, 3}, expected: true},
+		{name: "missing item", ids: []int{3, 1}, expected: false},
+		{name: "repeated item", ids: []int{1, 1, 2}, expected: false},
+		{name: "unknown item", ids: []int{1, 2, 4}, expected: false},
+	}
+
+	for _, tt := range testTable {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, sameItems(items, tt.ids))
+		})
+	}
+}
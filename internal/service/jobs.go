@@ -0,0 +1,384 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+	"todoai/internal/config"
+	"todoai/internal/models"
+	"todoai/internal/repository"
+	"todoai/pkg/lang"
+	"todoai/pkg/length"
+
+	"github.com/google/uuid"
+)
+
+var ErrQueueFull = errors.New("job queue is full")
+
+type JobsService interface {
+	SubmitFile(ctx context.Context, fileBytes []byte, filename string, opts models.SummaryOptions, userID int) (string, error)
+	SubmitText(ctx context.Context, text string, opts models.SummaryOptions, userID int) (string, error)
+	Get(ctx context.Context, jobID string, userID int) (models.Job, error)
+	Cancel(ctx context.Context, jobID string, userID int) error
+	Start(ctx context.Context) error
+	Stop(ctx context.Context) error
+}
+
+type jobsService struct {
+	// jobs returns the jobs repository.
+	jobs   func() repository.Jobs
+	log    *slog.Logger
+	cfg    *config.Config
+	upload Upload
+	usage  UsageService
+	modes  ModesService
+
+	queue   chan string
+	wg      sync.WaitGroup
+	ctx     context.Context
+	stop    context.CancelFunc
+	mu      sync.Mutex
+	cancels map[string]context.CancelFunc
+	// queued are the IDs waiting in the queue, so that a job is never queued twice.
+	queued map[string]bool
+}
+
+func NewJobsService(tm *TransactionManager, log *slog.Logger, cfg *config.Config, upload Upload, usage UsageService, modes ModesService) *jobsService {
+	return &jobsService{
+		jobs:    tm.NewJobsRepo,
+		log:     log,
+		cfg:     cfg,
+		upload:  upload,
+		usage:   usage,
+		modes:   modes,
+		queue:   make(chan string, cfg.Jobs.QueueSize),
+		cancels: make(map[string]context.CancelFunc),
+		queued:  make(map[string]bool),
+	}
+}
+
+func (s *jobsService) SubmitFile(ctx context.Context, fileBytes []byte, filename string, opts models.SummaryOptions, userID int) (string, error) {
+	return s.submit(ctx, &models.Job{UserID: userID, Options: opts, Filename: filename, Source: fileBytes})
+}
+
+func (s *jobsService) SubmitText(ctx context.Context, text string, opts models.SummaryOptions, userID int) (string, error) {
+	return s.submit(ctx, &models.Job{UserID: userID, Options: opts, Source: []byte(text)})
+}
+
+func (s *jobsService) Get(ctx context.Context, jobID string, userID int) (models.Job, error) {
+	const op = "service.jobsService.Get"
+	repo := s.jobs()
+	job, err := repo.GetByID(ctx, jobID, userID)
+	if err != nil {
+		if !errors.Is(err, repository.ErrJobNotFound) {
+			s.log.Error(op, "error", err)
+		}
+		return models.Job{}, err
+	}
+	return job, nil
+}
+
+// Cancel removes the job of userID from the store and stops it if a worker is running it.
+func (s *jobsService) Cancel(ctx context.Context, jobID string, userID int) error {
+	const op = "service.jobsService.Cancel"
+
+	repo := s.jobs()
+	if err := repo.Delete(ctx, jobID, userID); err != nil {
+		if !errors.Is(err, repository.ErrJobNotFound) {
+			s.log.Error(op, "error", err)
+		}
+		return err
+	}
+
+	s.mu.Lock()
+	if cancel, ok := s.cancels[jobID]; ok {
+		cancel()
+	}
+	s.mu.Unlock()
+	return nil
+}
+
+// Start re-queues the jobs left unfinished by a stopped instance and launches the worker pool
+// together with a sweeper that re-queues the jobs of instances that went away later.
+// Only jobs not updated for longer than the job timeout are taken, so running jobs of other
+// instances are not. A pending job that waits in a long queue may be taken too; whichever
+// instance claims it first runs it, and the other one skips it.
+func (s *jobsService) Start(ctx context.Context) error {
+	const op = "service.jobsService.Start"
+
+	repo := s.jobs()
+	ids, err := repo.ResetUnfinished(ctx, s.staleAfter())
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.ctx, s.stop = context.WithCancel(context.Background())
+	for i := 0; i < s.cfg.Jobs.Workers; i++ {
+		s.wg.Add(1)
+		go s.worker()
+	}
+	s.wg.Add(1)
+	go s.sweep()
+	s.resume(ids)
+
+	return nil
+}
+
+// Stop cancels running jobs and waits for the workers to exit. Interrupted and still queued
+// jobs are released, so the next Start of any instance picks them up again.
+func (s *jobsService) Stop(ctx context.Context) error {
+	s.stop()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		s.releaseQueued()
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// resume queues the jobs reset by ResetUnfinished. The jobs that do not fit into the queue are
+// released, so that the next sweep takes them again.
+func (s *jobsService) resume(ids []string) {
+	if len(ids) == 0 {
+		return
+	}
+	s.log.Info("resuming unfinished jobs", "count", len(ids))
+	for _, id := range ids {
+		if !s.enqueue(id) {
+			s.release(id)
+		}
+	}
+}
+
+// enqueue queues the job without blocking and reports whether it is in the queue. A job that
+// is already queued is not queued again.
+func (s *jobsService) enqueue(jobID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.queued[jobID] {
+		return true
+	}
+	select {
+	case s.queue <- jobID:
+		s.queued[jobID] = true
+		return true
+	default:
+		return false
+	}
+}
+
+// dequeued forgets a job taken off the queue.
+func (s *jobsService) dequeued(jobID string) {
+	s.mu.Lock()
+	delete(s.queued, jobID)
+	s.mu.Unlock()
+}
+
+// sweep re-queues the jobs that go stale while the instance runs, such as the jobs of another
+// instance that crashed.
+func (s *jobsService) sweep() {
+	const op = "service.jobsService.sweep"
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.cfg.Jobs.Timeout)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			ctx, cancel := s.newRequestContext()
+			ids, err := s.jobs().ResetUnfinished(ctx, s.staleAfter())
+			cancel()
+			if err != nil {
+				s.log.Error(op, "error", err)
+				continue
+			}
+			s.resume(ids)
+		}
+	}
+}
+
+// staleAfter is how long an unfinished job goes without updates before it is taken over. A job
+// that timed out is given the database timeout to record its failure.
+func (s *jobsService) staleAfter() time.Duration {
+	return s.cfg.Jobs.Timeout + s.cfg.Database.Timeout
+}
+
+// releaseQueued releases the jobs left in the queue by Stop.
+func (s *jobsService) releaseQueued() {
+	for {
+		select {
+		case id := <-s.queue:
+			s.dequeued(id)
+			s.release(id)
+		default:
+			return
+		}
+	}
+}
+
+func (s *jobsService) release(jobID string) {
+	const op = "service.jobsService.release"
+	ctx, cancel := s.newRequestContext()
+	defer cancel()
+	if err := s.jobs().Release(ctx, jobID); err != nil {
+		s.log.Error(op, "job_id", jobID, "error", err)
+	}
+}
+
+func (s *jobsService) submit(ctx context.Context, job *models.Job) (string, error) {
+	const op = "service.jobsService.submit"
+	if err := s.usage.Check(ctx, job.UserID); err != nil {
+		return "", err
+	}
+	if _, err := length.Parse(job.Options.Length); err != nil {
+		return "", err
+	}
+	if _, err := lang.Parse(job.Options.TargetLanguage); err != nil {
+		return "", err
+	}
+	mode, err := s.modes.Resolve(ctx, job.Options.Mode, job.UserID)
+	if err != nil {
+		return "", err
+	}
+	job.Mode, job.Options.Mode = mode.Name, mode.Name
+
+	job.ID = uuid.NewString()
+	repo := s.jobs()
+	if err := repo.Create(ctx, job); err != nil {
+		s.log.Error(op, "error", err)
+		return "", err
+	}
+
+	if s.enqueue(job.ID) {
+		return job.ID, nil
+	}
+	if err := repo.Delete(ctx, job.ID, job.UserID); err != nil {
+		s.log.Error(op, "error", err)
+	}
+	s.log.Warn(op, "error", ErrQueueFull)
+	return "", ErrQueueFull
+}
+
+func (s *jobsService) worker() {
+	defer s.wg.Done()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case id := <-s.queue:
+			s.dequeued(id)
+			s.run(id)
+		}
+	}
+}
+
+func (s *jobsService) run(jobID string) {
+	const op = "service.jobsService.run"
+	repo := s.jobs()
+
+	claimCtx, cancel := s.newRequestContext()
+	job, err := repo.Claim(claimCtx, jobID)
+	cancel()
+	if err != nil {
+		if !errors.Is(err, repository.ErrJobNotFound) {
+			s.log.Error(op, "job_id", jobID, "error", err)
+		}
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(s.ctx, s.cfg.Jobs.Timeout)
+	defer cancel()
+	s.mu.Lock()
+	s.cancels[jobID] = cancel
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.cancels, jobID)
+		s.mu.Unlock()
+	}()
+
+	ctx = WithProgress(ctx, &Progress{
+		ChunkDone: func(done, total int) {
+			dbCtx, cancel := s.newRequestContext()
+			defer cancel()
+			if err := repo.UpdateProgress(dbCtx, jobID, done, total); err != nil {
+				s.log.Error(op, "job_id", jobID, "error", err)
+			}
+		},
+	})
+
+	opts := job.Options
+	opts.Mode = job.Mode
+	var summary models.Summary
+	if job.Filename != "" {
+		summary, err = s.upload.ProcessFile(ctx, job.Source, job.Filename, opts, job.UserID)
+	} else {
+		summary, err = s.upload.ProcessText(ctx, string(job.Source), opts, job.UserID)
+	}
+
+	if s.ctx.Err() != nil {
+		s.log.Info("job interrupted by shutdown", "job_id", jobID)
+		s.release(jobID)
+		return
+	}
+
+	dbCtx, cancelDB := s.newRequestContext()
+	defer cancelDB()
+	if err != nil {
+		s.log.Warn(op, "job_id", jobID, "error", err)
+		if err := repo.Fail(dbCtx, jobID, jobErrorMessage(err)); err != nil {
+			s.log.Error(op, "job_id", jobID, "error", err)
+		}
+		return
+	}
+
+	if err := repo.Complete(dbCtx, jobID, summary); err != nil {
+		s.log.Error(op, "job_id", jobID, "error", err)
+	}
+}
+
+func (s *jobsService) newRequestContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), s.cfg.Database.Timeout)
+}
+
+func jobErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, ErrFileTooLarge):
+		return ErrFileTooLarge.Error()
+	case errors.Is(err, ErrUnsupportedFileType):
+		return ErrUnsupportedFileType.Error()
+	case errors.Is(err, ErrQuotaExceeded):
+		return ErrQuotaExceeded.Error()
+	case errors.Is(err, ErrUnknownMode):
+		return ErrUnknownMode.Error()
+	case errors.Is(err, length.ErrInvalid):
+		return length.ErrInvalid.Error()
+	case errors.Is(err, lang.ErrUnsupported):
+		return lang.ErrUnsupported.Error()
+	case errors.Is(err, ErrContentBlocked):
+		return ErrContentBlocked.Error()
+	case errors.Is(err, ErrSummaryTruncated):
+		return ErrSummaryTruncated.Error()
+	case errors.Is(err, ErrEmptySummary):
+		return ErrEmptySummary.Error()
+	case errors.Is(err, ErrInvalidStructuredSummary):
+		return ErrInvalidStructuredSummary.Error()
+	case errors.Is(err, context.DeadlineExceeded):
+		return "processing timed out"
+	default:
+		return "processing failed"
+	}
+}
@@ -0,0 +1,282 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+	"todoai/internal/config"
+	"todoai/internal/models"
+	"todoai/internal/repository"
+
+	"github.com/stretchr/testify/require"
+)
+
+// memoryJobs is an in-memory jobs repository. ResetUnfinished returns the stale jobs once.
+type memoryJobs struct {
+	mu       sync.Mutex
+	jobs     map[string]models.Job
+	stale    []string
+	released []string
+}
+
+func newMemoryJobs(jobs ...models.Job) *memoryJobs {
+	r := &memoryJobs{jobs: make(map[string]models.Job)}
+	for _, job := range jobs {
+		r.jobs[job.ID] = job
+	}
+	return r
+}
+
+func (r *memoryJobs) Create(ctx context.Context, job *models.Job) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job.Status = models.JobPending
+	r.jobs[job.ID] = *job
+	return nil
+}
+
+func (r *memoryJobs) GetByID(ctx context.Context, jobID string, userID int) (models.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[jobID]
+	if !ok || job.UserID != userID {
+		return models.Job{}, repository.ErrJobNotFound
+	}
+	return job, nil
+}
+
+func (r *memoryJobs) Claim(ctx context.Context, jobID string) (models.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[jobID]
+	if !ok || job.Status != models.JobPending {
+		return models.Job{}, repository.ErrJobNotFound
+	}
+	job.Status = models.JobRunning
+	r.jobs[jobID] = job
+	return job, nil
+}
+
+func (r *memoryJobs) UpdateProgress(ctx context.Context, jobID string, done, total int) error {
+	return nil
+}
+
+func (r *memoryJobs) Complete(ctx context.Context, jobID string, summary models.Summary) error {
+	return r.update(jobID, func(job *models.Job) {
+		job.Status, job.Result = models.JobDone, summary.Text
+	})
+}
+
+func (r *memoryJobs) Fail(ctx context.Context, jobID string, message string) error {
+	return r.update(jobID, func(job *models.Job) {
+		job.Status, job.Error = models.JobFailed, message
+	})
+}
+
+func (r *memoryJobs) Delete(ctx context.Context, jobID string, userID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if job, ok := r.jobs[jobID]; !ok || job.UserID != userID {
+		return repository.ErrJobNotFound
+	}
+	delete(r.jobs, jobID)
+	return nil
+}
+
+func (r *memoryJobs) ResetUnfinished(ctx context.Context, staleAfter time.Duration) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ids := r.stale
+	r.stale = nil
+	for _, id := range ids {
+		job := r.jobs[id]
+		job.Status = models.JobPending
+		r.jobs[id] = job
+	}
+	return ids, nil
+}
+
+func (r *memoryJobs) Release(ctx context.Context, jobID string) error {
+	r.mu.Lock()
+	r.released = append(r.released, jobID)
+	r.mu.Unlock()
+	return r.update(jobID, func(job *models.Job) {
+		job.Status = models.JobPending
+	})
+}
+
+func (r *memoryJobs) update(jobID string, fn func(job *models.Job)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if job, ok := r.jobs[jobID]; ok {
+		fn(&job)
+		r.jobs[jobID] = job
+	}
+	return nil
+}
+
+func (r *memoryJobs) status(jobID string) (models.JobStatus, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[jobID]
+	return job.Status, ok
+}
+
+// blockingUpload summarizes text at once unless it is "block", which runs until the context
+// is done.
+type blockingUpload struct {
+	started  chan string
+	finished chan error
+}
+
+func newBlockingUpload() *blockingUpload {
+	return &blockingUpload{started: make(chan string, 10), finished: make(chan error, 10)}
+}
+
+func (u *blockingUpload) ProcessFile(ctx context.Context, fileBytes []byte, filename string, opts models.SummaryOptions, userID int) (models.Summary, error) {
+	return u.ProcessText(ctx, string(fileBytes), opts, userID)
+}
+
+func (u *blockingUpload) ProcessText(ctx context.Context, text string, opts models.SummaryOptions, userID int) (models.Summary, error) {
+	u.started <- text
+	if text != "block" {
+		u.finished <- nil
+		return models.Summary{Text: "summary of " + text}, nil
+	}
+	<-ctx.Done()
+	u.finished <- ctx.Err()
+	return models.Summary{}, ctx.Err()
+}
+
+func newTestJobsService(repo *memoryJobs, upload Upload, timeout time.Duration) *jobsService {
+	cfg := &config.Config{}
+	cfg.Jobs.Workers = 1
+	cfg.Jobs.QueueSize = 10
+	cfg.Jobs.Timeout = timeout
+	cfg.Database.Timeout = time.Second
+
+	s := NewJobsService(nil, slog.New(slog.NewTextHandler(io.Discard, nil)), cfg, upload, nil, nil)
+	s.jobs = func() repository.Jobs { return repo }
+	return s
+}
+
+func TestJobsService_CancelQueued(t *testing.T) {
+	repo := newMemoryJobs(models.Job{ID: "queued", UserID: 1, Status: models.JobPending, Source: []byte("text")})
+	upload := newBlockingUpload()
+	s := newTestJobsService(repo, upload, time.Minute)
+	s.queue <- "queued"
+
+	require.NoError(t, s.Cancel(context.Background(), "queued", 1))
+	require.NoError(t, s.Start(context.Background()))
+
+	// The worker takes the job off the queue but finds nothing to claim.
+	require.Eventually(t, func() bool { return len(s.queue) == 0 }, time.Second, time.Millisecond)
+	require.NoError(t, s.Stop(context.Background()))
+	require.Empty(t, upload.started)
+	_, ok := repo.status("queued")
+	require.False(t, ok)
+}
+
+func TestJobsService_CancelRunning(t *testing.T) {
+	repo := newMemoryJobs(models.Job{ID: "running", UserID: 1, Status: models.JobPending, Source: []byte("block")})
+	upload := newBlockingUpload()
+	s := newTestJobsService(repo, upload, time.Minute)
+	require.NoError(t, s.Start(context.Background()))
+	defer s.Stop(context.Background())
+
+	s.queue <- "running"
+	require.Equal(t, "block", <-upload.started)
+
+	require.NoError(t, s.Cancel(context.Background(), "running", 1))
+	require.ErrorIs(t, <-upload.finished, context.Canceled)
+	_, ok := repo.status("running")
+	require.False(t, ok)
+}
+
+func TestJobsService_CancelUnknown(t *testing.T) {
+	repo := newMemoryJobs(models.Job{ID: "other", UserID: 2, Status: models.JobPending})
+	s := newTestJobsService(repo, newBlockingUpload(), time.Minute)
+
+	require.ErrorIs(t, s.Cancel(context.Background(), "other", 1), repository.ErrJobNotFound)
+	_, ok := repo.status("other")
+	require.True(t, ok)
+}
+
+func TestJobsService_ResumesOnStart(t *testing.T) {
+	repo := newMemoryJobs(
+		models.Job{ID: "a", UserID: 1, Status: models.JobRunning, Source: []byte("a")},
+		models.Job{ID: "b", UserID: 1, Status: models.JobPending, Source: []byte("b")},
+	)
+	repo.stale = []string{"a", "b"}
+	s := newTestJobsService(repo, newBlockingUpload(), time.Minute)
+	require.NoError(t, s.Start(context.Background()))
+	defer s.Stop(context.Background())
+
+	for _, id := range []string{"a", "b"} {
+		require.Eventually(t, func() bool {
+			status, _ := repo.status(id)
+			return status == models.JobDone
+		}, time.Second, time.Millisecond, fmt.Sprintf("job %s was not resumed", id))
+	}
+}
+
+func TestJobsService_Timeout(t *testing.T) {
+	repo := newMemoryJobs(models.Job{ID: "slow", UserID: 1, Status: models.JobPending, Source: []byte("block")})
+	s := newTestJobsService(repo, newBlockingUpload(), 20*time.Millisecond)
+	require.NoError(t, s.Start(context.Background()))
+	defer s.Stop(context.Background())
+
+	s.queue <- "slow"
+	require.Eventually(t, func() bool {
+		status, _ := repo.status("slow")
+		return status == models.JobFailed
+	}, time.Second, time.Millisecond)
+
+	job, err := repo.GetByID(context.Background(), "slow", 1)
+	require.NoError(t, err)
+	require.Equal(t, "processing timed out", job.Error)
+}
+
+func TestJobsService_StopReleasesRunningJob(t *testing.T) {
+	repo := newMemoryJobs(models.Job{ID: "running", UserID: 1, Status: models.JobPending, Source: []byte("block")})
+	upload := newBlockingUpload()
+	s := newTestJobsService(repo, upload, time.Minute)
+	require.NoError(t, s.Start(context.Background()))
+
+	s.queue <- "running"
+	<-upload.started
+	require.NoError(t, s.Stop(context.Background()))
+
+	status, ok := repo.status("running")
+	require.True(t, ok)
+	require.Equal(t, models.JobPending, status)
+}
+
+func TestJobsService_EnqueueOnce(t *testing.T) {
+	s := newTestJobsService(newMemoryJobs(), newBlockingUpload(), time.Minute)
+
+	require.True(t, s.enqueue("a"))
+	require.True(t, s.enqueue("a"))
+	require.Len(t, s.queue, 1)
+
+	s.dequeued(<-s.queue)
+	require.True(t, s.enqueue("a"))
+	require.Len(t, s.queue, 1)
+}
+
+func TestJobsService_ResumeReleasesOverflow(t *testing.T) {
+	repo := newMemoryJobs(
+		models.Job{ID: "a", UserID: 1, Status: models.JobPending},
+		models.Job{ID: "b", UserID: 1, Status: models.JobPending},
+	)
+	s := newTestJobsService(repo, newBlockingUpload(), time.Minute)
+	s.queue = make(chan string, 1)
+
+	s.resume([]string{"a", "b"})
+	require.Equal(t, "a", <-s.queue)
+	require.Equal(t, []string{"b"}, repo.released)
+}
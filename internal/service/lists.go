@@ -2,15 +2,26 @@ package service
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"todoai/internal/models"
+	"todoai/internal/repository"
+)
+
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
 )
 
 type ListsService interface {
 	Create(ctx context.Context, list *models.List, userID int) error
-	Get(ctx context.Context, userID int) ([]models.List, error)
+	// Get returns a page of the user's lists. The sort, order and limit of the query default
+	// when they are not set.
+	Get(ctx context.Context, query models.ListQuery, userID int) (models.ListPage, error)
 	GetByID(ctx context.Context, listID int, userID int) (models.List, error)
-	UpdateOnlyBody(ctx context.Context, listID int, text string, userID int) error
+	// Update applies the partial update to the list. A non-zero version must match the current
+	// version of the list, otherwise repository.ErrVersionConflict is returned.
+	Update(ctx context.Context, listID int, update models.ListUpdate, version int, userID int) (models.List, error)
 	Delete(ctx context.Context, listID int, userID int) error
 }
 
@@ -34,21 +45,49 @@ func (s *listsService) Create(ctx context.Context, list *models.List, userID int
 	return nil
 }
 
-func (s *listsService) Get(ctx context.Context, userID int) ([]models.List, error) {
+func (s *listsService) Get(ctx context.Context, query models.ListQuery, userID int) (models.ListPage, error) {
 	const op = "service.listsService.Get"
 	repo := s.tm.NewListsRepo()
-	lists, err := repo.Get(ctx, userID)
+	page, err := repo.Get(ctx, listQueryDefaults(query), userID)
 	if err != nil {
-		s.log.Error(op, "error", err)
-		return nil, err
+		if !errors.Is(err, repository.ErrInvalidCursor) {
+			s.log.Error(op, "error", err)
+		}
+		return models.ListPage{}, err
+	}
+	return page, nil
+}
+
+// listQueryDefaults fills in the sort, order and limit of the query.
+func listQueryDefaults(query models.ListQuery) models.ListQuery {
+	if query.Sort == "" {
+		query.Sort = models.ListSortCreated
+	}
+	if query.Order == "" {
+		query.Order = "desc"
+		if query.Sort == models.ListSortTitle {
+			query.Order = "asc"
+		}
+	}
+	if query.Limit == 0 {
+		query.Limit = defaultListLimit
 	}
-	return lists, nil
+	query.Limit = min(query.Limit, maxListLimit)
+	return query
 }
 
+// GetByID returns the list together with its items.
 func (s *listsService) GetByID(ctx context.Context, listID int, userID int) (models.List, error) {
 	const op = "service.listsService.GetByID"
 	repo := s.tm.NewListsRepo()
 	list, err := repo.GetByID(ctx, listID, userID)
+	if err != nil {
+		if !errors.Is(err, repository.ErrListNotFound) {
+			s.log.Error(op, "error", err)
+		}
+		return models.List{}, err
+	}
+	list.Items, err = s.tm.NewItemsRepo().GetByList(ctx, listID, userID)
 	if err != nil {
 		s.log.Error(op, "error", err)
 		return models.List{}, err
@@ -56,15 +95,17 @@ func (s *listsService) GetByID(ctx context.Context, listID int, userID int) (mod
 	return list, nil
 }
 
-func (s *listsService) UpdateOnlyBody(ctx context.Context, listID int, text string, userID int) error {
-	const op = "service.listsService.UpdateOnlyBody"
+func (s *listsService) Update(ctx context.Context, listID int, update models.ListUpdate, version int, userID int) (models.List, error) {
+	const op = "service.listsService.Update"
 	repo := s.tm.NewListsRepo()
-	err := repo.UpdateBody(ctx, listID, text, userID)
+	list, err := repo.Update(ctx, listID, update, version, userID)
 	if err != nil {
-		s.log.Error(op, "error", err)
-		return err
+		if !errors.Is(err, repository.ErrListNotFound) && !errors.Is(err, repository.ErrVersionConflict) {
+			s.log.Error(op, "error", err)
+		}
+		return models.List{}, err
 	}
-	return nil
+	return list, nil
 }
 
 func (s *listsService) Delete(ctx context.Context, listID int, userID int) error {
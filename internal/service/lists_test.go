@@ -0,0 +1,43 @@
+package service
+
+import (
+	"testing"
+	"todoai/internal/models"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListQueryDefaults(t *testing.T) {
+	testTable := []struct {
+		name     string
+		query    models.ListQuery
+		expected models.ListQuery
+	}{
+		{
+			name:     "empty",
+			query:    models.ListQuery{},
+			expected: models.ListQuery{Sort: models.ListSortCreated, Order: "desc", Limit: defaultListLimit},
+		},
+		{
+			name:     "title ascending",
+			query:    models.ListQuery{Sort: models.ListSortTitle},
+			expected: models.ListQuery{Sort: models.ListSortTitle, Order: "asc", Limit: defaultListLimit},
+		},
+		{
+			name:     "explicit",
+			query:    models.ListQuery{Sort: models.ListSortUpdated, Order: "asc", Limit: 5, Title: "trip", Cursor: "abc"},
+			expected: models.ListQuery{Sort: models.ListSortUpdated, Order: "asc", Limit: 5, Title: "trip", Cursor: "abc"},
+		},
+		{
+			name:     "limit capped",
+			query:    models.ListQuery{Limit: 1000},
+			expected: models.ListQuery{Sort: models.ListSortCreated, Order: "desc", Limit: maxListLimit},
+		},
+	}
+
+	for _, tt := range testTable {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, listQueryDefaults(tt.query))
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sort"
+	"todoai/internal/config"
+	"todoai/internal/models"
+	"todoai/internal/repository"
+	"todoai/pkg/prompt"
+)
+
+var (
+	ErrUnknownMode     = errors.New("unknown mode")
+	ErrInvalidModeName = errors.New("mode name may only contain lowercase letters, digits, '-' and '_'")
+	ErrBuiltInMode     = errors.New("a built-in mode has this name")
+	ErrInvalidPrompt   = errors.New("invalid prompt template")
+)
+
+var modeName = regexp.MustCompile(`^[a-z0-9_-]+$`)
+
+type ModesService interface {
+	// Get returns the built-in modes followed by the user's own ones.
+	Get(ctx context.Context, userID int) ([]models.Mode, error)
+	// Resolve finds a mode visible to the user by name and parses its prompts.
+	// An empty name means Summarizer.DefaultMode.
+	Resolve(ctx context.Context, name string, userID int) (ResolvedMode, error)
+	Create(ctx context.Context, mode *models.Mode, userID int) error
+	Update(ctx context.Context, mode *models.Mode, userID int) error
+	Delete(ctx context.Context, modeID int, userID int) error
+}
+
+// ResolvedMode is a mode with its prompt templates parsed.
+type ResolvedMode struct {
+	models.Mode
+	Compressor *prompt.Template
+	Formatter  *prompt.Template
+}
+
+type modesService struct {
+	tm      *TransactionManager
+	log     *slog.Logger
+	cfg     *config.Config
+	prompts *prompt.Set
+	builtIn map[string]ResolvedMode
+}
+
+// NewModesService parses the prompts of the built-in modes, so that a broken template stops
+// the server at startup.
+func NewModesService(tm *TransactionManager, log *slog.Logger, cfg *config.Config, prompts *prompt.Set) (*modesService, error) {
+	s := &modesService{tm: tm, log: log, cfg: cfg, prompts: prompts}
+	s.builtIn = make(map[string]ResolvedMode, len(cfg.Modes))
+	for name, mode := range cfg.Modes {
+		resolved, err := s.parse(models.Mode{
+			Name:             name,
+			CompressorPrompt: mode.Compressor,
+			FormatterPrompt:  mode.Formatter,
+			CompressTemp:     mode.CompressTemp,
+			FormatTemp:       mode.FormatTemp,
+			BuiltIn:          true,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("mode %s: %w", name, err)
+		}
+		s.builtIn[name] = resolved
+	}
+	return s, nil
+}
+
+func (s *modesService) Get(ctx context.Context, userID int) ([]models.Mode, error) {
+	const op = "service.modesService.Get"
+
+	modes := make([]models.Mode, 0, len(s.builtIn))
+	for _, mode := range s.builtIn {
+		modes = append(modes, mode.Mode)
+	}
+	sort.Slice(modes, func(i, j int) bool { return modes[i].Name < modes[j].Name })
+
+	if userID == 0 {
+		return modes, nil
+	}
+
+	repo := s.tm.NewModesRepo()
+	own, err := repo.Get(ctx, userID)
+	if err != nil {
+		s.log.Error(op, "error", err)
+		return nil, err
+	}
+	return append(modes, own...), nil
+}
+
+func (s *modesService) Resolve(ctx context.Context, name string, userID int) (ResolvedMode, error) {
+	const op = "service.modesService.Resolve"
+	if name == "" {
+		name = s.cfg.Summarizer.DefaultMode
+	}
+	if mode, ok := s.builtIn[name]; ok {
+		return mode, nil
+	}
+	if userID == 0 {
+		return ResolvedMode{}, ErrUnknownMode
+	}
+
+	repo := s.tm.NewModesRepo()
+	mode, err := repo.GetByName(ctx, name, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrModeNotFound) {
+			return ResolvedMode{}, ErrUnknownMode
+		}
+		s.log.Error(op, "error", err)
+		return ResolvedMode{}, err
+	}
+
+	// The prompts were checked when the mode was saved, so this only fails when the
+	// partials in the config have changed since.
+	resolved, err := s.parse(mode)
+	if err != nil {
+		s.log.Error(op, "error", err)
+		return ResolvedMode{}, err
+	}
+	return resolved, nil
+}
+
+func (s *modesService) Create(ctx context.Context, mode *models.Mode, userID int) error {
+	const op = "service.modesService.Create"
+	if err := s.validate(mode); err != nil {
+		return err
+	}
+
+	repo := s.tm.NewModesRepo()
+	if err := repo.Create(ctx, mode, userID); err != nil {
+		if !errors.Is(err, repository.ErrModeExists) {
+			s.log.Error(op, "error", err)
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *modesService) Update(ctx context.Context, mode *models.Mode, userID int) error {
+	const op = "service.modesService.Update"
+	if err := s.validate(mode); err != nil {
+		return err
+	}
+
+	repo := s.tm.NewModesRepo()
+	if err := repo.Update(ctx, mode, userID); err != nil {
+		if !errors.Is(err, repository.ErrModeExists) && !errors.Is(err, repository.ErrModeNotFound) {
+			s.log.Error(op, "error", err)
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *modesService) Delete(ctx context.Context, modeID int, userID int) error {
+	const op = "service.modesService.Delete"
+	repo := s.tm.NewModesRepo()
+	if err := repo.Delete(ctx, modeID, userID); err != nil {
+		if !errors.Is(err, repository.ErrModeNotFound) {
+			s.log.Error(op, "error", err)
+		}
+		return err
+	}
+	return nil
+}
+
+// validate checks the name and the prompts of a user mode. Built-in names are reserved so that
+// a user mode never hides a built-in one.
+func (s *modesService) validate(mode *models.Mode) error {
+	if !modeName.MatchString(mode.Name) {
+		return ErrInvalidModeName
+	}
+	if _, ok := s.builtIn[mode.Name]; ok {
+		return ErrBuiltInMode
+	}
+	mode.BuiltIn = false
+	_, err := s.parse(*mode)
+	return err
+}
+
+// parse parses the prompts of a mode. The prompts of user modes are held to the restricted
+// syntax of prompt.Set.ParseUser.
+func (s *modesService) parse(mode models.Mode) (ResolvedMode, error) {
+	parse := s.prompts.ParseUser
+	if mode.BuiltIn {
+		parse = s.prompts.Parse
+	}
+	compressor, err := parse("compressor", mode.CompressorPrompt)
+	if err != nil {
+		return ResolvedMode{}, fmt.Errorf("%w: %w", ErrInvalidPrompt, err)
+	}
+	formatter, err := parse("formatter", mode.FormatterPrompt)
+	if err != nil {
+		return ResolvedMode{}, fmt.Errorf("%w: %w", ErrInvalidPrompt, err)
+	}
+	return ResolvedMode{Mode: mode, Compressor: compressor, Formatter: formatter}, nil
+}
@@ -0,0 +1,51 @@
+package service
+
+import "context"
+
+// Progress receives summarization events from Upload while a document is processed.
+// Any hook may be nil.
+type Progress struct {
+	// ChunkDone is called once the number of chunks is known and after every compressed chunk.
+	ChunkDone func(done, total int)
+	// ChunkSummary is called with the compressed text of every chunk.
+	ChunkSummary func(index int, summary string)
+	// ReduceLevel is called before every level of summary reduction with the number of groups
+	// that are compressed again.
+	ReduceLevel func(level, groups int)
+	// Token is called with every piece of the final formatter output as the model streams it.
+	// When set, the formatter pass is run through AI.GenerateStream.
+	Token func(text string)
+}
+
+type progressKey struct{}
+
+// WithProgress returns a copy of ctx that reports summarization progress to p.
+func WithProgress(ctx context.Context, p *Progress) context.Context {
+	return context.WithValue(ctx, progressKey{}, p)
+}
+
+func progressFromContext(ctx context.Context) *Progress {
+	p, _ := ctx.Value(progressKey{}).(*Progress)
+	if p == nil {
+		return &Progress{}
+	}
+	return p
+}
+
+func (p *Progress) chunkDone(done, total int) {
+	if p.ChunkDone != nil {
+		p.ChunkDone(done, total)
+	}
+}
+
+func (p *Progress) reduceLevel(level, groups int) {
+	if p.ReduceLevel != nil {
+		p.ReduceLevel(level, groups)
+	}
+}
+
+func (p *Progress) chunkSummary(index int, summary string) {
+	if p.ChunkSummary != nil {
+		p.ChunkSummary(index, summary)
+	}
+}
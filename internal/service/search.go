@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"todoai/internal/models"
+	"todoai/internal/repository"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 50
+	maxSearchQuery     = 256
+)
+
+var ErrInvalidSearchQuery = errors.New("the search query must not be empty or longer than 256 characters")
+
+type SearchService interface {
+	// Search returns the user's lists and summaries matching the query, best match first.
+	Search(ctx context.Context, query string, limit int, userID int) ([]models.SearchResult, error)
+}
+
+type searchService struct {
+	log *slog.Logger
+	// search returns the search repository.
+	search func() repository.Search
+}
+
+func NewSearchService(tm *TransactionManager, log *slog.Logger) *searchService {
+	return &searchService{search: tm.NewSearchRepo, log: log}
+}
+
+func (s *searchService) Search(ctx context.Context, query string, limit int, userID int) ([]models.SearchResult, error) {
+	const op = "service.searchService.Search"
+	query = strings.TrimSpace(query)
+	if query == "" || len([]rune(query)) > maxSearchQuery {
+		return nil, ErrInvalidSearchQuery
+	}
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	limit = min(limit, maxSearchLimit)
+
+	repo := s.search()
+	results, err := repo.Search(ctx, query, limit, userID)
+	if err != nil {
+		s.log.Error(op, "error", err)
+		return nil, err
+	}
+	return results, nil
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"todoai/internal/models"
+	"todoai/internal/repository"
+
+	"github.com/stretchr/testify/require"
+)
+
+// recordingSearch remembers the last search it was asked for.
+type recordingSearch struct {
+	query string
+	limit int
+}
+
+func (r *recordingSearch) Search(ctx context.Context, query string, limit int, userID int) ([]models.SearchResult, error) {
+	r.query, r.limit = query, limit
+	return []models.SearchResult{}, nil
+}
+
+func TestSearchService_Search(t *testing.T) {
+	testTable := []struct {
+		name          string
+		query         string
+		limit         int
+		expectedQuery string
+		expectedLimit int
+		expectedErr   error
+	}{
+		{name: "default limit", query: "договор", limit: 0, expectedQuery: "договор", expectedLimit: defaultSearchLimit},
+		{name: "limit kept", query: "contract", limit: 5, expectedQuery: "contract", expectedLimit: 5},
+		{name: "limit capped", query: "contract", limit: 1000, expectedQuery: "contract", expectedLimit: maxSearchLimit},
+		{name: "query trimmed", query: "  contract  ", expectedQuery: "contract", expectedLimit: defaultSearchLimit},
+		{name: "empty query", query: "   ", expectedErr: ErrInvalidSearchQuery},
+		{name: "longest query", query: strings.Repeat("я", maxSearchQuery), expectedQuery: strings.Repeat("я", maxSearchQuery), expectedLimit: defaultSearchLimit},
+		{name: "too long query", query: strings.Repeat("я", maxSearchQuery+1), expectedErr: ErrInvalidSearchQuery},
+	}
+
+	for _, tt := range testTable {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &recordingSearch{}
+			s := NewSearchService(nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+			s.search = func() repository.Search { return repo }
+
+			_, err := s.Search(context.Background(), tt.query, tt.limit, 1)
+			if tt.expectedErr != nil {
+				require.ErrorIs(t, err, tt.expectedErr)
+				require.Zero(t, repo.limit)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedQuery, repo.query)
+			require.Equal(t, tt.expectedLimit, repo.limit)
+		})
+	}
+}
@@ -2,23 +2,58 @@ package service
 
 import (
 	"database/sql"
+	"fmt"
 	"log/slog"
 	"todoai/internal/config"
 	"todoai/internal/gateway/ai"
 	"todoai/pkg/jwt"
 	"todoai/pkg/mail"
+	"todoai/pkg/prompt"
 )
 
 type Service struct {
-	Auth   AuthService
-	Lists  ListsService
-	Upload Upload
+	Auth      AuthService
+	Lists     ListsService
+	Items     ItemsService
+	Upload    Upload
+	Jobs      JobsService
+	Usage     UsageService
+	Summaries SummariesService
+	Modes     ModesService
+	Todo      TodoService
+	Search    SearchService
 }
 
-func NewService(db *sql.DB, tm *TransactionManager, log *slog.Logger, cfg *config.Config, sender mail.Sender, jwt jwt.JWT, ai ai.AI) *Service {
-	return &Service{
-		Auth:   NewAuthService(tm, log, cfg, sender, jwt),
-		Lists:  NewListsService(tm, log),
-		Upload: NewFileService(cfg, log, ai),
+func NewService(db *sql.DB, tm *TransactionManager, log *slog.Logger, cfg *config.Config, sender mail.Sender, jwt jwt.JWT, ai ai.AI) (*Service, error) {
+	lists := NewListsService(tm, log)
+	usage := NewUsageService(tm, log, cfg)
+	summaries := NewSummariesService(tm, log, lists)
+	prompts, err := prompt.NewSet(cfg.Prompts)
+	if err != nil {
+		return nil, fmt.Errorf("parse prompts: %w", err)
+	}
+	modes, err := NewModesService(tm, log, cfg, prompts)
+	if err != nil {
+		return nil, err
+	}
+	upload, err := NewFileService(cfg, log, ai, usage, summaries, modes, prompts)
+	if err != nil {
+		return nil, err
 	}
+	todo, err := NewTodoService(tm, log, cfg, ai, usage, upload, prompts)
+	if err != nil {
+		return nil, err
+	}
+	return &Service{
+		Auth:      NewAuthService(tm, log, cfg, sender, jwt),
+		Lists:     lists,
+		Items:     NewItemsService(tm, log),
+		Upload:    upload,
+		Jobs:      NewJobsService(tm, log, cfg, upload, usage, modes),
+		Usage:     usage,
+		Summaries: summaries,
+		Modes:     modes,
+		Todo:      todo,
+		Search:    NewSearchService(tm, log),
+	}, nil
 }
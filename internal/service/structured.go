@@ -0,0 +1,178 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"todoai/internal/gateway/ai"
+	"todoai/internal/models"
+)
+
+// structuredSchema is the schema of models.StructuredSummary sent to the model.
+var structuredSchema = &ai.Schema{
+	Type: ai.TypeObject,
+	Properties: map[string]*ai.Schema{
+		"title":    {Type: ai.TypeString, Description: "A short title of the document."},
+		"abstract": {Type: ai.TypeString, Description: "The essence of the document in a few sentences."},
+		"key_points": {
+			Type:        ai.TypeArray,
+			Description: "The most important facts, arguments or conclusions.",
+			Items:       &ai.Schema{Type: ai.TypeString},
+		},
+		"entities": {
+			Type:        ai.TypeArray,
+			Description: "Named entities the document is about.",
+			Items: &ai.Schema{
+				Type: ai.TypeObject,
+				Properties: map[string]*ai.Schema{
+					"name": {Type: ai.TypeString},
+					"type": {Type: ai.TypeString, Enum: models.EntityTypes},
+				},
+				Required: []string{"name", "type"},
+			},
+		},
+		"language": {Type: ai.TypeString, Description: "ISO 639-1 code of the language of the summary."},
+		"sections": {
+			Type:        ai.TypeArray,
+			Description: "The main sections of the document in order.",
+			Items: &ai.Schema{
+				Type: ai.TypeObject,
+				Properties: map[string]*ai.Schema{
+					"title":   {Type: ai.TypeString},
+					"summary": {Type: ai.TypeString},
+				},
+				Required: []string{"title", "summary"},
+			},
+		},
+	},
+	Required: []string{"title", "abstract", "key_points", "entities", "language", "sections"},
+	Validate: func(text string) error {
+		_, err := parseStructured(text)
+		return err
+	},
+}
+
+// structure turns the joined chunk summaries into a models.StructuredSummary. An answer that
+// does not match the schema is sent back to the model once, together with its problems.
+// The returned Result holds the validated object re-encoded as JSON.
+func (s *file) structure(ctx context.Context, content string, chunkCount int, req *summaryRequest) (ai.Result, error) {
+	const op = "service.Structure"
+	vars := req.instructions.finalVars()
+	if req.instructions.Budget.IsZero() {
+		vars.MaxSentences = req.instructions.Vars.MaxSentences
+	}
+	vars.ChunkCount = chunkCount
+
+	instruction, err := s.structured.Render(vars)
+	if err != nil {
+		s.log.Error(op, "error", err)
+		return ai.Result{}, err
+	}
+	result, err := s.ai.GenerateJSON(ctx, content, instruction, req.instructions.FormatTemp, structuredSchema)
+	s.account(ctx, result, err, req)
+	if err != nil {
+		s.log.Error(op, "error", err)
+		return ai.Result{}, summaryError(err)
+	}
+
+	structured, problems := parseStructured(result.Text)
+	if problems != nil {
+		s.log.Warn(op, "message", "invalid structured summary, asking to repair it", "error", problems)
+
+		instruction, err := s.repair.Render(vars)
+		if err != nil {
+			s.log.Error(op, "error", err)
+			return ai.Result{}, err
+		}
+		prompt := fmt.Sprintf("JSON:\n%s\n\nProblems:\n%s", result.Text, problems)
+		result, err = s.ai.GenerateJSON(ctx, prompt, instruction, req.instructions.FormatTemp, structuredSchema)
+		s.account(ctx, result, err, req)
+		if err != nil {
+			s.log.Error(op, "error", err)
+			return ai.Result{}, summaryError(err)
+		}
+
+		structured, problems = parseStructured(result.Text)
+		if problems != nil {
+			s.log.Error(op, "error", problems)
+			return ai.Result{}, fmt.Errorf("%w: %w", ErrInvalidStructuredSummary, problems)
+		}
+	}
+
+	encoded, err := json.Marshal(structured)
+	if err != nil {
+		return ai.Result{}, fmt.Errorf("%s: %w", op, err)
+	}
+	result.Text = string(encoded)
+	return result, nil
+}
+
+// parseStructured decodes a model answer and checks it against structuredSchema. All problems
+// are reported at once so that they can be sent back to the model.
+func parseStructured(text string) (models.StructuredSummary, error) {
+	var structured models.StructuredSummary
+	decoder := json.NewDecoder(strings.NewReader(text))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&structured); err != nil {
+		return models.StructuredSummary{}, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if decoder.More() {
+		return models.StructuredSummary{}, errors.New("invalid JSON: data after the object")
+	}
+
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(strings.TrimSpace(structured.Title) != "", "title is empty")
+	check(strings.TrimSpace(structured.Abstract) != "", "abstract is empty")
+	check(strings.TrimSpace(structured.Language) != "", "language is empty")
+	check(len(structured.KeyPoints) > 0, "key_points is empty")
+	for i, point := range structured.KeyPoints {
+		check(strings.TrimSpace(point) != "", "key_points[%d] is empty", i)
+	}
+	for i, entity := range structured.Entities {
+		check(strings.TrimSpace(entity.Name) != "", "entities[%d].name is empty", i)
+		check(slices.Contains(models.EntityTypes, entity.Type), "entities[%d].type must be one of %s", i, strings.Join(models.EntityTypes, ", "))
+	}
+	for i, section := range structured.Sections {
+		check(strings.TrimSpace(section.Title) != "", "sections[%d].title is empty", i)
+		check(strings.TrimSpace(section.Summary) != "", "sections[%d].summary is empty", i)
+	}
+	if len(errs) > 0 {
+		return models.StructuredSummary{}, errors.Join(errs...)
+	}
+
+	// Absent lists are returned as empty ones.
+	if structured.Entities == nil {
+		structured.Entities = []models.Entity{}
+	}
+	if structured.Sections == nil {
+		structured.Sections = []models.SectionSummary{}
+	}
+	return structured, nil
+}
+
+// structuredText renders a structured summary as plain text for the history and for clients
+// that only read the text.
+func structuredText(structured models.StructuredSummary) string {
+	var b bytes.Buffer
+	b.WriteString(structured.Title)
+	b.WriteString("\n\n")
+	b.WriteString(structured.Abstract)
+	b.WriteString("\n\n")
+	for _, point := range structured.KeyPoints {
+		fmt.Fprintf(&b, "- %s\n", point)
+	}
+	for _, section := range structured.Sections {
+		fmt.Fprintf(&b, "\n%s\n%s\n", section.Title, section.Summary)
+	}
+	return strings.TrimSpace(b.String())
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"testing"
+	"todoai/internal/models"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStructured(t *testing.T) {
+	testTable := []struct {
+		name        string
+		text        string
+		expected    models.StructuredSummary
+		expectedErr []string
+	}{
+		{
+			name: "OK",
+			text: `{"title":"T","abstract":"A.","key_points":["P."],"entities":[{"name":"Moscow","type":"location"}],
+				"language":"en","sections":[{"title":"S","summary":"Sum."}]}`,
+			expected: models.StructuredSummary{
+				Title:     "T",
+				Abstract:  "A.",
+				KeyPoints: []string{"P."},
+				Entities:  []models.Entity{{Name: "Moscow", Type: models.EntityLocation}},
+				Language:  "en",
+				Sections:  []models.SectionSummary{{Title: "S", Summary: "Sum."}},
+			},
+		},
+		{
+			name: "missing lists are empty",
+			text: `{"title":"T","abstract":"A.","key_points":["P."],"language":"en"}`,
+			expected: models.StructuredSummary{
+				Title:     "T",
+				Abstract:  "A.",
+				KeyPoints: []string{"P."},
+				Entities:  []models.Entity{},
+				Language:  "en",
+				Sections:  []models.SectionSummary{},
+			},
+		},
+		{
+			name:        "not JSON",
+			text:        `Here is the summary: {"title":"T"}`,
+			expectedErr: []string{"invalid JSON"},
+		},
+		{
+			name:        "unknown field",
+			text:        `{"title":"T","summary":"A."}`,
+			expectedErr: []string{"invalid JSON", "unknown field"},
+		},
+		{
+			name: "every problem is reported",
+			text: `{"title":" ","abstract":"A.","key_points":[],"entities":[{"name":"X","type":"planet"}],"language":"en"}`,
+			expectedErr: []string{
+				"title is empty",
+				"key_points is empty",
+				"entities[0].type must be one of person, organization, location, date, other",
+			},
+		},
+	}
+
+	for _, tt := range testTable {
+		t.Run(tt.name, func(t *testing.T) {
+			structured, err := parseStructured(tt.text)
+			if tt.expectedErr != nil {
+				require.Error(t, err)
+				for _, expected := range tt.expectedErr {
+					require.Contains(t, err.Error(), expected)
+				}
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, structured)
+		})
+	}
+}
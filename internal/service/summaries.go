@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"todoai/internal/models"
+	"todoai/internal/repository"
+	"unicode/utf8"
+)
+
+const (
+	// maxListTitle is the length of lists.title.
+	maxListTitle = 64
+	// defaultSummaryLimit is the page size used when the caller does not ask for one.
+	defaultSummaryLimit = 20
+	maxSummaryLimit     = 100
+)
+
+type SummariesService interface {
+	Save(ctx context.Context, summary *models.Summary, userID int) error
+	Get(ctx context.Context, limit, offset int, userID int) (models.SummaryPage, error)
+	GetByID(ctx context.Context, summaryID int, userID int) (models.Summary, error)
+	Delete(ctx context.Context, summaryID int, userID int) error
+	// SaveAsList creates a list holding the summary text. An empty title is made from the
+	// summary's file name and mode.
+	SaveAsList(ctx context.Context, summaryID int, title string, userID int) error
+}
+
+type summariesService struct {
+	tm    *TransactionManager
+	log   *slog.Logger
+	lists ListsService
+}
+
+func NewSummariesService(tm *TransactionManager, log *slog.Logger, lists ListsService) *summariesService {
+	return &summariesService{tm: tm, log: log, lists: lists}
+}
+
+func (s *summariesService) Save(ctx context.Context, summary *models.Summary, userID int) error {
+	const op = "service.summariesService.Save"
+	repo := s.tm.NewSummariesRepo()
+	if err := repo.Create(ctx, summary, userID); err != nil {
+		s.log.Error(op, "error", err)
+		return err
+	}
+	return nil
+}
+
+func (s *summariesService) Get(ctx context.Context, limit, offset int, userID int) (models.SummaryPage, error) {
+	const op = "service.summariesService.Get"
+	if limit <= 0 {
+		limit = defaultSummaryLimit
+	}
+	limit = min(limit, maxSummaryLimit)
+	offset = max(offset, 0)
+
+	repo := s.tm.NewSummariesRepo()
+	summaries, total, err := repo.Get(ctx, limit, offset, userID)
+	if err != nil {
+		s.log.Error(op, "error", err)
+		return models.SummaryPage{}, err
+	}
+
+	return models.SummaryPage{
+		Summaries: summaries,
+		Total:     total,
+		Limit:     limit,
+		Offset:    offset,
+	}, nil
+}
+
+func (s *summariesService) GetByID(ctx context.Context, summaryID int, userID int) (models.Summary, error) {
+	const op = "service.summariesService.GetByID"
+	repo := s.tm.NewSummariesRepo()
+	summary, err := repo.GetByID(ctx, summaryID, userID)
+	if err != nil {
+		if !errors.Is(err, repository.ErrSummaryNotFound) {
+			s.log.Error(op, "error", err)
+		}
+		return models.Summary{}, err
+	}
+	return summary, nil
+}
+
+func (s *summariesService) Delete(ctx context.Context, summaryID int, userID int) error {
+	const op = "service.summariesService.Delete"
+	repo := s.tm.NewSummariesRepo()
+	if err := repo.Delete(ctx, summaryID, userID); err != nil {
+		if !errors.Is(err, repository.ErrSummaryNotFound) {
+			s.log.Error(op, "error", err)
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *summariesService) SaveAsList(ctx context.Context, summaryID int, title string, userID int) error {
+	summary, err := s.GetByID(ctx, summaryID, userID)
+	if err != nil {
+		return err
+	}
+
+	title = strings.TrimSpace(title)
+	if title == "" {
+		title = summaryTitle(summary)
+	}
+	list := models.List{
+		Title: truncateRunes(title, maxListTitle),
+		Body:  summary.Text,
+	}
+	return s.lists.Create(ctx, &list, userID)
+}
+
+func summaryTitle(summary models.Summary) string {
+	if summary.Filename != "" {
+		return summary.Filename
+	}
+	return "Summary (" + summary.Mode + ") " + summary.CreatedAt.Format("2006-01-02 15:04")
+}
+
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
@@ -0,0 +1,267 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+	"todoai/internal/config"
+	"todoai/internal/gateway/ai"
+	"todoai/internal/models"
+	"todoai/pkg/lang"
+	"todoai/pkg/prompt"
+	"todoai/pkg/reader"
+
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// maxItemTitle and maxItemOwner are the lengths of models.Item text and owner.
+	maxItemTitle = 256
+	maxItemOwner = 128
+)
+
+var (
+	// ErrInvalidTodoList means the model did not return a valid JSON todo list.
+	ErrInvalidTodoList = errors.New("the model returned an invalid todo list")
+	ErrNoActionItems   = errors.New("no action items found in the document")
+)
+
+// todoSchema is the schema of a todo list sent to the model.
+var todoSchema = &ai.Schema{
+	Type: ai.TypeObject,
+	Properties: map[string]*ai.Schema{
+		"title":       {Type: ai.TypeString, Description: "A short title of the todo list."},
+		"description": {Type: ai.TypeString, Description: "What the list is about in one or two sentences."},
+		"items": {
+			Type:        ai.TypeArray,
+			Description: "The action items in the order of the document.",
+			Items: &ai.Schema{
+				Type: ai.TypeObject,
+				Properties: map[string]*ai.Schema{
+					"title":    {Type: ai.TypeString, Description: "The action as a short imperative phrase."},
+					"due":      {Type: ai.TypeString, Description: "The due date as YYYY-MM-DD."},
+					"owner":    {Type: ai.TypeString, Description: "Who is responsible for the action."},
+					"priority": {Type: ai.TypeString, Enum: models.Priorities},
+				},
+				Required: []string{"title"},
+			},
+		},
+	},
+	Required: []string{"title", "description", "items"},
+	Validate: func(text string) error {
+		_, err := parseTodoList(text)
+		return err
+	},
+}
+
+// todoList is a todo list as the model returns it.
+type todoList struct {
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Items       []todoItem `json:"items"`
+}
+
+type todoItem struct {
+	Title    string `json:"title"`
+	Due      string `json:"due,omitempty"`
+	Owner    string `json:"owner,omitempty"`
+	Priority string `json:"priority,omitempty"`
+}
+
+type TodoService interface {
+	// GenerateFromFile and GenerateFromText extract the action items of a document and save
+	// them as a new list of the user. The model tokens are charged to the user.
+	GenerateFromFile(ctx context.Context, fileBytes []byte, filename string, userID int) (models.List, error)
+	GenerateFromText(ctx context.Context, text string, userID int) (models.List, error)
+}
+
+type todoService struct {
+	cfg     *config.Config
+	log     *slog.Logger
+	tm      *TransactionManager
+	ai      ai.AI
+	usage   UsageService
+	files   *file
+	extract *prompt.Template
+	merge   *prompt.Template
+}
+
+func NewTodoService(tm *TransactionManager, log *slog.Logger, cfg *config.Config, ai ai.AI, usage UsageService, files *file, prompts *prompt.Set) (*todoService, error) {
+	s := &todoService{cfg: cfg, log: log, tm: tm, ai: ai, usage: usage, files: files}
+
+	var err error
+	if s.extract, err = prompts.Parse("extract", cfg.Todo.Extract); err != nil {
+		return nil, fmt.Errorf("parse todo extract prompt: %w", err)
+	}
+	if s.merge, err = prompts.Parse("merge", cfg.Todo.Merge); err != nil {
+		return nil, fmt.Errorf("parse todo merge prompt: %w", err)
+	}
+	return s, nil
+}
+
+func (s *todoService) GenerateFromFile(ctx context.Context, fileBytes []byte, filename string, userID int) (models.List, error) {
+	const op = "service.todoService.GenerateFromFile"
+	if err := s.usage.Check(ctx, userID); err != nil {
+		return models.List{}, err
+	}
+	doc, err := s.files.extract(fileBytes, filename)
+	if err != nil {
+		s.log.Error(op, "error", err)
+		return models.List{}, err
+	}
+	return s.generate(ctx, doc, filename, userID)
+}
+
+func (s *todoService) GenerateFromText(ctx context.Context, text string, userID int) (models.List, error) {
+	if err := s.usage.Check(ctx, userID); err != nil {
+		return models.List{}, err
+	}
+	return s.generate(ctx, reader.Document{Text: text}, "", userID)
+}
+
+// generate extracts the action items of every chunk of the document, merges the lists of
+// a document of several chunks into one and saves the result together with its items in
+// one transaction.
+func (s *todoService) generate(ctx context.Context, doc reader.Document, filename string, userID int) (models.List, error) {
+	const op = "service.todoService.generate"
+	language := lang.Detect(doc.Text)
+	chunks := s.files.spliter(doc.Text, language, s.files.tokenSizer(ctx, doc.Text))
+	if len(chunks) == 0 {
+		return models.List{}, ErrNoActionItems
+	}
+	if len(chunks) > s.cfg.Summarizer.MaxLen {
+		return models.List{}, ErrFileTooLarge
+	}
+
+	lists := make([]todoList, len(chunks))
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(max(s.cfg.Summarizer.Workers, 1))
+	for i, chunk := range chunks {
+		if gctx.Err() != nil {
+			break
+		}
+		g.Go(func() error {
+			vars := prompt.Vars{ChunkIndex: i + 1, ChunkCount: len(chunks), Filename: filename}
+			list, err := s.ask(gctx, s.extract, vars, chunk.Text, userID)
+			lists[i] = list
+			return err
+		})
+	}
+	if err := g.Wait(); err != nil {
+		s.log.Error(op, "error", err)
+		return models.List{}, summaryError(err)
+	}
+
+	list := lists[0]
+	if len(lists) > 1 {
+		content, err := json.Marshal(lists)
+		if err != nil {
+			return models.List{}, fmt.Errorf("%s: %w", op, err)
+		}
+		vars := prompt.Vars{ChunkCount: len(chunks), Filename: filename}
+		if list, err = s.ask(ctx, s.merge, vars, string(content), userID); err != nil {
+			s.log.Error(op, "error", err)
+			return models.List{}, summaryError(err)
+		}
+	}
+
+	result := newList(list, filename)
+	if len(result.Items) == 0 {
+		return models.List{}, ErrNoActionItems
+	}
+
+	err := s.tm.WithTransaction(ctx, func(repos *TransactionalRepos) error {
+		if err := repos.Lists.Create(ctx, &result, userID); err != nil {
+			return err
+		}
+		for i := range result.Items {
+			result.Items[i].ListID = result.ID
+			if err := repos.Items.Create(ctx, &result.Items[i], userID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		s.log.Error(op, "error", err)
+		return models.List{}, err
+	}
+	return result, nil
+}
+
+// ask sends the content to the model with the rendered instruction and decodes the todo list
+// it answers with. The tokens are charged to the user.
+func (s *todoService) ask(ctx context.Context, instruction *prompt.Template, vars prompt.Vars, content string, userID int) (todoList, error) {
+	text, err := instruction.Render(vars)
+	if err != nil {
+		return todoList{}, err
+	}
+	result, err := s.ai.GenerateJSON(ctx, content, text, s.cfg.Summarizer.CompressTemp, todoSchema)
+	recordUsage(ctx, s.usage, s.log, "service.todoService.ask", result, userID)
+	if err != nil {
+		return todoList{}, err
+	}
+	return parseTodoList(result.Text)
+}
+
+// parseTodoList decodes the answer of the model. Fields of the items that are not valid are
+// dropped rather than failing the whole list.
+func parseTodoList(text string) (todoList, error) {
+	var list todoList
+	if err := json.Unmarshal([]byte(text), &list); err != nil {
+		return todoList{}, fmt.Errorf("%w: %w", ErrInvalidTodoList, err)
+	}
+
+	items := make([]todoItem, 0, len(list.Items))
+	for _, item := range list.Items {
+		item.Title = truncateRunes(strings.TrimSpace(item.Title), maxItemTitle)
+		if item.Title == "" {
+			continue
+		}
+		item.Due = strings.TrimSpace(item.Due)
+		if _, err := time.Parse(time.DateOnly, item.Due); err != nil {
+			item.Due = ""
+		}
+		item.Owner = truncateRunes(strings.TrimSpace(item.Owner), maxItemOwner)
+		item.Priority = strings.ToLower(strings.TrimSpace(item.Priority))
+		if !slices.Contains(models.Priorities, item.Priority) {
+			item.Priority = ""
+		}
+		items = append(items, item)
+	}
+	list.Title = strings.TrimSpace(list.Title)
+	list.Description = strings.TrimSpace(list.Description)
+	list.Items = items
+	return list, nil
+}
+
+// newList turns a todo list of the model into a list to save. A list without a title is named
+// after the file.
+func newList(list todoList, filename string) models.List {
+	title := list.Title
+	if title == "" {
+		title = filename
+	}
+	if title == "" {
+		title = "Action items"
+	}
+	result := models.List{
+		Title: truncateRunes(title, maxListTitle),
+		Body:  list.Description,
+		Items: make([]models.Item, len(list.Items)),
+	}
+	for i, item := range list.Items {
+		result.Items[i] = models.Item{
+			Text:     item.Title,
+			Due:      item.Due,
+			Owner:    item.Owner,
+			Priority: item.Priority,
+		}
+	}
+	return result
+}
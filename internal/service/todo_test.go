@@ -0,0 +1,72 @@
+package service
+
+import (
+	"testing"
+	"todoai/internal/models"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTodoList(t *testing.T) {
+	testTable := []struct {
+		name        string
+		text        string
+		expected    todoList
+		expectedErr error
+	}{
+		{
+			name: "OK",
+			text: `{"title":"Contract","description":"Obligations of the buyer.","items":[
+				{"title":"Pay the deposit","due":"2026-11-01","owner":"Buyer","priority":"high"},
+				{"title":"Sign the act"}]}`,
+			expected: todoList{
+				Title:       "Contract",
+				Description: "Obligations of the buyer.",
+				Items: []todoItem{
+					{Title: "Pay the deposit", Due: "2026-11-01", Owner: "Buyer", Priority: models.PriorityHigh},
+					{Title: "Sign the act"},
+				},
+			},
+		},
+		{
+			name: "invalid fields are dropped",
+			text: `{"title":" T ","description":"","items":[
+				{"title":" ","due":"2026-11-01"},
+				{"title":"Call the bank","due":"next Monday","priority":"URGENT"},
+				{"title":"Send the report","priority":"Low"}]}`,
+			expected: todoList{
+				Title: "T",
+				Items: []todoItem{
+					{Title: "Call the bank"},
+					{Title: "Send the report", Priority: models.PriorityLow},
+				},
+			},
+		},
+		{
+			name:        "not JSON",
+			text:        `Here are the items: - pay`,
+			expectedErr: ErrInvalidTodoList,
+		},
+	}
+
+	for _, tt := range testTable {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := parseTodoList(tt.text)
+			if tt.expectedErr != nil {
+				require.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, list)
+		})
+	}
+}
+
+func TestNewList(t *testing.T) {
+	list := newList(todoList{Items: []todoItem{{Title: "Pay", Due: "2026-11-01"}}}, "contract.pdf")
+
+	require.Equal(t, models.List{
+		Title: "contract.pdf",
+		Items: []models.Item{{Text: "Pay", Due: "2026-11-01"}},
+	}, list)
+}
@@ -23,9 +23,34 @@ func (tm *TransactionManager) NewListsRepo() repository.Lists {
 	return repository.NewListsRepo(tm.db)
 }
 
+func (tm *TransactionManager) NewItemsRepo() repository.Items {
+	return repository.NewItemsRepo(tm.db)
+}
+
+func (tm *TransactionManager) NewJobsRepo() repository.Jobs {
+	return repository.NewJobsRepo(tm.db)
+}
+
+func (tm *TransactionManager) NewModesRepo() repository.Modes {
+	return repository.NewModesRepo(tm.db)
+}
+
+func (tm *TransactionManager) NewSummariesRepo() repository.Summaries {
+	return repository.NewSummariesRepo(tm.db)
+}
+
+func (tm *TransactionManager) NewSearchRepo() repository.Search {
+	return repository.NewSearchRepo(tm.db)
+}
+
+func (tm *TransactionManager) NewUsageRepo() repository.Usage {
+	return repository.NewUsageRepo(tm.db)
+}
+
 type TransactionalRepos struct {
 	Auth  repository.Auth
 	Lists repository.Lists
+	Items repository.Items
 }
 
 func (tm *TransactionManager) WithTransaction(ctx context.Context, fn func(repos *TransactionalRepos) error) error {
@@ -38,6 +63,7 @@ func (tm *TransactionManager) WithTransaction(ctx context.Context, fn func(repos
 	transactionalRepo := &TransactionalRepos{
 		Auth:  repository.NewAuthRepo(tx),
 		Lists: repository.NewListsRepo(tx),
+		Items: repository.NewItemsRepo(tx),
 	}
 
 	if err := fn(transactionalRepo); err != nil {
@@ -2,51 +2,179 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
+	"math"
 	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 	"todoai/internal/config"
 	"todoai/internal/gateway/ai"
+	"todoai/internal/models"
+	"todoai/pkg/lang"
+	"todoai/pkg/length"
+	"todoai/pkg/prompt"
+	"todoai/pkg/reader"
 	"todoai/pkg/reader/docx"
 	"todoai/pkg/reader/pdf"
+	"todoai/pkg/splitter"
+	"unicode/utf8"
+
+	"golang.org/x/sync/errgroup"
 )
 
 const (
-	bookMode          = "book"
-	docMode           = "doc"
-	articleMode       = "article"
-	jurisprudenceMode = "jurisprudence"
-	defaultMode       = "default"
+	// maxReduceLevels bounds the depth of the summary reduction.
+	maxReduceLevels = 8
+	// tokenSampleSize is how many bytes of a document are sent to the tokenizer.
+	tokenSampleSize = 32 << 10
+	// overshootFactor is how far a text summary may exceed the requested length
+	// before the formatter is asked once more to shorten it.
+	overshootFactor = 1.5
 )
 
-var ErrFileTooLarge = errors.New("the file is too large")
+var (
+	ErrFileTooLarge        = errors.New("the file is too large")
+	ErrUnsupportedFileType = errors.New("unsupported file type")
+	ErrContentBlocked      = errors.New("the document was blocked by the model safety filters")
+	ErrSummaryTruncated    = errors.New("the summary exceeded the model output limit")
+	ErrEmptySummary        = errors.New("the model returned an empty summary")
+	// ErrInvalidStructuredSummary means the model did not return a valid JSON summary,
+	// even after being asked to repair it.
+	ErrInvalidStructuredSummary = errors.New("the model returned an invalid structured summary")
+)
 
-type textExtractor func(fileBytes []byte) (string, error)
+type textExtractor func(fileBytes []byte) (reader.Document, error)
 
 type Upload interface {
-	ProcessFile(ctx context.Context, fileBytes []byte, filename string, mode string) (string, error)
-	ProcessText(ctx context.Context, text string, mode string) (string, error)
+	// ProcessFile and ProcessText charge the model tokens to userID and save the summary to the
+	// user's history; zero means an anonymous caller, whose summaries are not kept.
+	ProcessFile(ctx context.Context, fileBytes []byte, filename string, opts models.SummaryOptions, userID int) (models.Summary, error)
+	ProcessText(ctx context.Context, text string, opts models.SummaryOptions, userID int) (models.Summary, error)
 }
 
 type file struct {
 	config     *config.Config
 	log        *slog.Logger
 	ai         ai.AI
+	usage      UsageService
+	summaries  SummariesService
+	modes      ModesService
 	extractors map[string]textExtractor
+	// layouts are the layout instructions by format.
+	layouts map[string]*prompt.Template
+	// structured and repair are the prompts of output=json.
+	structured *prompt.Template
+	repair     *prompt.Template
 }
 
+// processingInstructions are the prompt templates of a mode and of the requested layout together
+// with the request-level variables they are rendered with.
 type processingInstructions struct {
-	CompressorInstruction string
-	FormatterInstruction  string
+	Compressor   *prompt.Template
+	Formatter    *prompt.Template
+	Layout       *prompt.Template
+	Vars         prompt.Vars
+	CompressTemp float32
+	FormatTemp   float32
+	// Length is the requested summary length and Budget its resolution for the source text,
+	// set by compress. A zero Budget keeps Vars.MaxSentences for the chunks and does not limit
+	// the final text.
+	Length length.Spec
+	Budget length.Budget
+	// TargetLanguage is the code of the language the final text is translated to, and
+	// SourceLanguage the detected code of the document language, set by compress.
+	// Chunk summaries always stay in the document language.
+	TargetLanguage string
+	SourceLanguage string
+	// Citations asks the model to keep the source tags of the chunk summaries.
+	Citations bool
+}
+
+// compressor renders the compressor instruction for the chunk with the 0-based index.
+// Level is zero for the chunks of the source and the reduction level for chunk summaries.
+func (p processingInstructions) compressor(index, count, level int) (string, error) {
+	vars := p.Vars
+	vars.ChunkIndex, vars.ChunkCount = index+1, count
+	vars.Citations = p.Citations && level > 0
+	if !p.Budget.IsZero() {
+		share := p.Budget.Share(count)
+		vars.MaxSentences, vars.MaxWords = share.Sentences, share.Words
+	}
+	return p.Compressor.Render(vars)
+}
+
+// formatter renders the formatter instruction, followed by the layout one, for a document
+// of count chunks.
+func (p processingInstructions) formatter(count int) (string, error) {
+	vars := p.finalVars()
+	vars.ChunkCount = count
+	formatter, err := p.Formatter.Render(vars)
+	if err != nil {
+		return "", err
+	}
+	layout, err := p.Layout.Render(vars)
+	if err != nil {
+		return "", err
+	}
+	return formatter + "\n\n" + layout, nil
+}
+
+// finalVars are the variables of the prompts that write the final summary: the whole budget,
+// or no length at all when the request sets none, and the language to translate to.
+func (p processingInstructions) finalVars() prompt.Vars {
+	vars := p.Vars
+	vars.MaxSentences, vars.MaxWords = p.Budget.Sentences, p.Budget.Words
+	vars.Language = lang.Name(p.TargetLanguage)
+	vars.Citations = p.Citations
+	if p.SourceLanguage != p.TargetLanguage {
+		vars.SourceLanguage = lang.Name(p.SourceLanguage)
+	}
+	return vars
+}
+
+// summaryRequest is shared by all model calls made for one document.
+type summaryRequest struct {
+	userID       int
+	output       string
+	instructions processingInstructions
+	// sources are the chunks of the source the citations point to.
+	sources     []models.SourceRange
+	cacheHits   atomic.Int64
+	cacheMisses atomic.Int64
 }
 
-func NewFileService(config *config.Config, log *slog.Logger, ai ai.AI) *file {
+func NewFileService(config *config.Config, log *slog.Logger, ai ai.AI, usage UsageService, summaries SummariesService, modes ModesService, prompts *prompt.Set) (*file, error) {
 	s := &file{
-		config: config,
-		log:    log,
-		ai:     ai,
+		config:    config,
+		log:       log,
+		ai:        ai,
+		usage:     usage,
+		summaries: summaries,
+		modes:     modes,
+	}
+
+	s.layouts = make(map[string]*prompt.Template, len(models.Formats))
+	for _, format := range models.Formats {
+		layout, err := prompts.Parse(format, config.Formats[format])
+		if err != nil {
+			return nil, fmt.Errorf("parse %s layout: %w", format, err)
+		}
+		s.layouts[format] = layout
+	}
+
+	var err error
+	if s.structured, err = prompts.Parse("structured", config.Structured.Prompt); err != nil {
+		return nil, fmt.Errorf("parse structured prompt: %w", err)
+	}
+	if s.repair, err = prompts.Parse("repair", config.Structured.Repair); err != nil {
+		return nil, fmt.Errorf("parse repair prompt: %w", err)
 	}
 
 	s.extractors = map[string]textExtractor{
@@ -58,133 +186,428 @@ func NewFileService(config *config.Config, log *slog.Logger, ai ai.AI) *file {
 		".docx": s.extractFromDocx,
 	}
 
-	return s
+	return s, nil
 }
 
-func (s *file) ProcessFile(ctx context.Context, fileBytes []byte, filename string, mode string) (string, error) {
+func (s *file) ProcessFile(ctx context.Context, fileBytes []byte, filename string, opts models.SummaryOptions, userID int) (models.Summary, error) {
 	const op = "service.ProcessFile"
-	fileExt := strings.ToLower(filepath.Ext(filename))
-	extractor, ok := s.extractors[fileExt]
-	if !ok {
-		s.log.Error(op, "error", fmt.Errorf("unsupported file type: %s", fileExt))
-		return "", errors.New("unsupported file type")
+	started := time.Now()
+	if err := s.usage.Check(ctx, userID); err != nil {
+		return models.Summary{}, err
 	}
-	text, err := extractor(fileBytes)
+	doc, err := s.extract(fileBytes, filename)
 	if err != nil {
 		s.log.Error(op, "error", err)
-		return "", err
+		return models.Summary{}, err
 	}
-	instructions, err := s.getInstructions(mode)
+	resolved, instructions, err := s.getInstructions(ctx, opts, userID)
 	if err != nil {
 		s.log.Error(op, "error", err)
-		return "", err
+		return models.Summary{}, err
 	}
-	return s.compress(ctx, text, instructions)
+	instructions.Vars.Filename = filename
+	return s.summarize(ctx, doc, models.Summary{Filename: filename, Mode: resolved.Name}, opts, instructions, userID, started)
 }
 
-func (s *file) ProcessText(ctx context.Context, text string, mode string) (string, error) {
+func (s *file) ProcessText(ctx context.Context, text string, opts models.SummaryOptions, userID int) (models.Summary, error) {
 	const op = "service.ProcessText"
-	instructions, err := s.getInstructions(mode)
+	started := time.Now()
+	if err := s.usage.Check(ctx, userID); err != nil {
+		return models.Summary{}, err
+	}
+	resolved, instructions, err := s.getInstructions(ctx, opts, userID)
 	if err != nil {
 		s.log.Error(op, "error", err)
-		return "", err
+		return models.Summary{}, err
 	}
-	return s.compress(ctx, text, instructions)
+	return s.summarize(ctx, reader.Document{Text: text}, models.Summary{Mode: resolved.Name}, opts, instructions, userID, started)
 }
 
-func (s *file) compress(ctx context.Context, fileText string, instructions processingInstructions) (string, error) {
+// summarize compresses the document into summary and saves it to the user's history.
+// A summary that could not be saved is still returned, without an ID.
+func (s *file) summarize(ctx context.Context, doc reader.Document, summary models.Summary, opts models.SummaryOptions, instructions processingInstructions, userID int, started time.Time) (models.Summary, error) {
+	const op = "service.Summarize"
+	req := &summaryRequest{userID: userID, output: opts.Output, instructions: instructions}
+	result, err := s.compress(ctx, doc, req)
+	if err != nil {
+		return models.Summary{}, err
+	}
+
+	if s.config.Cache.Enabled {
+		summary.Cache = &models.CacheStats{
+			Hits:   int(req.cacheHits.Load()),
+			Misses: int(req.cacheMisses.Load()),
+		}
+		s.log.Info("summary done", "mode", summary.Mode, "cache_hits", summary.Cache.Hits, "cache_misses", summary.Cache.Misses)
+	}
+
+	hash := sha256.Sum256([]byte(doc.Text))
+	summary.TextHash = hex.EncodeToString(hash[:])
+	summary.SourceLanguage = req.instructions.SourceLanguage
+	summary.TargetLanguage = req.instructions.TargetLanguage
+	if req.output == models.OutputJSON {
+		// compress has already validated the object.
+		var structured models.StructuredSummary
+		if err := json.Unmarshal([]byte(result.Text), &structured); err != nil {
+			return models.Summary{}, fmt.Errorf("%s: %w", op, err)
+		}
+		summary.Structured = &structured
+		summary.Text = structuredText(structured)
+	} else {
+		text := result.Text
+		if req.instructions.Citations {
+			text, summary.Citations = extractCitations(text, req.sources)
+		}
+		summary.Format = formatOrDefault(opts.Format)
+		summary.Text, summary.Outline = formatText(summary.Format, text)
+	}
+	summary.Model = result.Model
+	summary.DurationMs = time.Since(started).Milliseconds()
+	summary.CreatedAt = time.Now()
+
+	if userID != 0 {
+		if err := s.summaries.Save(ctx, &summary, userID); err != nil {
+			s.log.Warn(op, "message", "summary not saved to history", "error", err)
+		}
+	}
+	return summary, nil
+}
+
+// compress summarizes the document in map-reduce fashion: every chunk is compressed, the chunk
+// summaries are reduced level by level until they fit into a single prompt, and the formatter
+// turns the result into the final text. When citations are requested, every sentence of a chunk
+// summary is tagged with the chunk so that the final text can point back to it.
+// Summarizer.MaxLen, or Anonymous.MaxChunks for anonymous callers, caps the number of chunks
+// and therefore the cost of a single document. The tokens of every model call are charged
+// to the user of req.
+func (s *file) compress(ctx context.Context, doc reader.Document, req *summaryRequest) (ai.Result, error) {
 	const op = "service.Compress"
-	chunks := s.spliter(fileText)
-	if len(chunks) > s.config.Summarizer.MaxLen {
-		return "", ErrFileTooLarge
+	req.instructions.SourceLanguage = lang.Detect(doc.Text)
+	size := s.tokenSizer(ctx, doc.Text)
+	sourceChunks := s.spliter(doc.Text, req.instructions.SourceLanguage, size)
+	maxChunks := s.config.Summarizer.MaxLen
+	if req.userID == 0 {
+		maxChunks = s.config.Anonymous.MaxChunks
 	}
-	var content strings.Builder
-	for _, v := range chunks {
-		part, err := s.ai.Generate(ctx, v, instructions.CompressorInstruction, s.config.Summarizer.CompressTemp)
-		if err != nil {
-			s.log.Error(op, "error", err)
-			return "", err
+	if len(sourceChunks) > maxChunks {
+		return ai.Result{}, ErrFileTooLarge
+	}
+	req.instructions.Budget = req.instructions.Length.Budget(length.Words(doc.Text))
+	if req.instructions.Citations {
+		req.sources = sourceRanges(doc, sourceChunks)
+	}
+	chunks := make([]string, len(sourceChunks))
+	for i, chunk := range sourceChunks {
+		chunks[i] = chunk.Text
+	}
+
+	progress := progressFromContext(ctx)
+	progress.chunkDone(0, len(chunks))
+	done := 0
+	parts, err := s.compressChunks(ctx, chunks, req, 0, func(index int, part string) {
+		done++
+		progress.chunkSummary(index, part)
+		progress.chunkDone(done, len(chunks))
+	})
+	if err != nil {
+		s.log.Error(op, "error", err)
+		return ai.Result{}, summaryError(err)
+	}
+	if req.instructions.Citations {
+		for i := range parts {
+			parts[i] = tagSentences(parts[i], i+1, req.instructions.SourceLanguage)
 		}
+	}
+
+	parts, err = s.reduce(ctx, parts, req, size, progress)
+	if err != nil {
+		s.log.Error(op, "error", err)
+		return ai.Result{}, summaryError(err)
+	}
+
+	var content strings.Builder
+	for _, part := range parts {
 		content.WriteString(fmt.Sprintf("%s\n", part))
 	}
 
-	finalText, err := s.ai.Generate(ctx, content.String(), instructions.FormatterInstruction, s.config.Summarizer.FormatTemp)
+	// The length budget of a structured summary is only asked of the abstract in the prompt;
+	// shorten works on text and is not applied to it.
+	if req.output == models.OutputJSON {
+		return s.structure(ctx, content.String(), len(chunks), req)
+	}
+
+	instruction, err := req.instructions.formatter(len(chunks))
 	if err != nil {
 		s.log.Error(op, "error", err)
-		return "", err
+		return ai.Result{}, err
 	}
-	return finalText, nil
+
+	var result ai.Result
+	if progress.Token != nil {
+		result, err = s.ai.GenerateStream(ctx, content.String(), instruction, req.instructions.FormatTemp, progress.Token)
+	} else {
+		result, err = s.ai.Generate(ctx, content.String(), instruction, req.instructions.FormatTemp)
+	}
+	s.account(ctx, result, err, req)
+	if err != nil {
+		s.log.Error(op, "error", err)
+		return ai.Result{}, summaryError(err)
+	}
+	// A streamed answer has already been shown to the client, so it is not replaced by a
+	// shorter one that the client would never see.
+	if progress.Token != nil {
+		return result, nil
+	}
+	return s.shorten(ctx, result, instruction, req), nil
+}
+
+// shorten asks the model once more for a summary that overshoots the requested length badly.
+// The first answer is kept when the second one fails or is no shorter.
+func (s *file) shorten(ctx context.Context, result ai.Result, instruction string, req *summaryRequest) ai.Result {
+	const op = "service.Shorten"
+	budget := req.instructions.Budget
+	if budget.IsZero() || !budget.Exceeds(result.Text, overshootFactor) {
+		return result
+	}
+
+	var target, actual string
+	if budget.Words > 0 {
+		target, actual = fmt.Sprintf("%d words", budget.Words), fmt.Sprintf("%d words", length.Words(result.Text))
+	} else {
+		target, actual = fmt.Sprintf("%d sentences", budget.Sentences), fmt.Sprintf("%d sentences", length.Sentences(result.Text))
+	}
+	s.log.Warn(op, "message", "summary is too long, asking to shorten it", "target", target, "actual", actual)
+
+	instruction += fmt.Sprintf("\n\nThe input is a summary of %s that must be shortened to %s. Keep the most important points and the layout.", actual, target)
+	shorter, err := s.ai.Generate(ctx, result.Text, instruction, req.instructions.FormatTemp)
+	s.account(ctx, shorter, err, req)
+	if err != nil {
+		s.log.Warn(op, "message", "summary not shortened", "error", err)
+		return result
+	}
+	if length.Words(shorter.Text) >= length.Words(result.Text) {
+		return result
+	}
+	return shorter
+}
+
+// summaryError tags a failed model response with the service error describing it,
+// so that callers do not have to know about the ai package.
+func summaryError(err error) error {
+	switch {
+	case errors.Is(err, ai.ErrBlocked):
+		return fmt.Errorf("%w: %w", ErrContentBlocked, err)
+	case errors.Is(err, ai.ErrTruncated):
+		return fmt.Errorf("%w: %w", ErrSummaryTruncated, err)
+	case errors.Is(err, ai.ErrEmptyResponse):
+		return fmt.Errorf("%w: %w", ErrEmptySummary, err)
+	default:
+		return err
+	}
+}
+
+// account charges the tokens of a model call to the user and counts cache hits and misses.
+func (s *file) account(ctx context.Context, result ai.Result, err error, req *summaryRequest) {
+	recordUsage(ctx, s.usage, s.log, "service.account", result, req.userID)
+
+	switch {
+	case result.Cached:
+		req.cacheHits.Add(1)
+	case err == nil:
+		req.cacheMisses.Add(1)
+	}
+}
+
+// reduce re-compresses groups of summaries until their joined text fits into one chunk.
+// A level that does not make the text any shorter stops the reduction early.
+func (s *file) reduce(ctx context.Context, parts []string, req *summaryRequest, sizeOf splitter.SizeFunc, progress *Progress) ([]string, error) {
+	size := sizeOf(strings.Join(parts, "\n"))
+	for level := 1; size > s.config.Summarizer.ChunkSize && level <= maxReduceLevels; level++ {
+		groups := s.group(parts, sizeOf)
+		progress.reduceLevel(level, len(groups))
+
+		reduced, err := s.compressChunks(ctx, groups, req, level, func(int, string) {})
+		if err != nil {
+			return nil, err
+		}
+
+		reducedSize := sizeOf(strings.Join(reduced, "\n"))
+		s.log.Debug("summary reduced", "level", level, "groups", len(groups), "size", size, "reduced_size", reducedSize)
+		if reducedSize >= size {
+			break
+		}
+		parts, size = reduced, reducedSize
+	}
+	return parts, nil
+}
+
+// group joins neighbouring summaries into groups of at most Summarizer.ChunkSize. Each group
+// holds at least two summaries so that every level shrinks the number of parts.
+func (s *file) group(parts []string, sizeOf splitter.SizeFunc) []string {
+	var groups []string
+	var current []string
+	currentSize := 0
+	for _, part := range parts {
+		partSize := sizeOf(part)
+		if len(current) >= 2 && currentSize+partSize > s.config.Summarizer.ChunkSize {
+			groups = append(groups, strings.Join(current, "\n"))
+			current, currentSize = nil, 0
+		}
+		current = append(current, part)
+		currentSize += partSize
+	}
+	if len(current) > 0 {
+		groups = append(groups, strings.Join(current, "\n"))
+	}
+	return groups
+}
+
+// compressChunks runs the compressor over all chunks with at most Summarizer.Workers requests
+// in flight. Summaries keep the order of the chunks; the first failure cancels the rest.
+// level is zero for the chunks of the source and the reduction level for chunk summaries.
+// onDone is called for every compressed chunk, one call at a time.
+func (s *file) compressChunks(ctx context.Context, chunks []string, req *summaryRequest, level int, onDone func(index int, part string)) ([]string, error) {
+	parts := make([]string, len(chunks))
+
+	var mu sync.Mutex
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(max(s.config.Summarizer.Workers, 1))
+	for i, chunk := range chunks {
+		if gctx.Err() != nil {
+			break
+		}
+		g.Go(func() error {
+			instruction, err := req.instructions.compressor(i, len(chunks), level)
+			if err != nil {
+				return err
+			}
+			result, err := s.ai.Generate(gctx, chunk, instruction, req.instructions.CompressTemp)
+			s.account(ctx, result, err, req)
+			if err != nil {
+				return err
+			}
+			parts[i] = result.Text
+
+			mu.Lock()
+			defer mu.Unlock()
+			onDone(i, result.Text)
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	return parts, nil
 }
 
-func (s *file) extractFromPlainText(fileBytes []byte) (string, error) {
-	return string(fileBytes), nil
+// extract reads the text of the file with the extractor of its extension.
+func (s *file) extract(fileBytes []byte, filename string) (reader.Document, error) {
+	fileExt := strings.ToLower(filepath.Ext(filename))
+	extractor, ok := s.extractors[fileExt]
+	if !ok {
+		return reader.Document{}, fmt.Errorf("%w: %s", ErrUnsupportedFileType, fileExt)
+	}
+	return extractor(fileBytes)
+}
+
+func (s *file) extractFromPlainText(fileBytes []byte) (reader.Document, error) {
+	return reader.Document{Text: string(fileBytes)}, nil
 }
 
-func (s *file) extractFromPDF(fileBytes []byte) (string, error) {
+func (s *file) extractFromPDF(fileBytes []byte) (reader.Document, error) {
 	const op = "sdervice.ExtractFromPDF"
-	text, err := pdf.ReadPDF(fileBytes)
+	doc, err := pdf.ReadPDF(fileBytes)
 	if err != nil {
 		s.log.Error(op, "error", err)
-		return "", err
+		return reader.Document{}, err
 	}
-	return text, nil
+	return doc, nil
 }
 
-func (s *file) extractFromDocx(fileBytes []byte) (string, error) {
+func (s *file) extractFromDocx(fileBytes []byte) (reader.Document, error) {
 	const op = "service.ExtractFromDocx"
-	text, err := docx.ReadDocx(fileBytes)
+	doc, err := docx.ReadDocx(fileBytes)
 	if err != nil {
 		s.log.Error(op, "error", err)
-		return "", err
+		return reader.Document{}, err
 	}
-	return text, nil
-}
-
-func (s *file) getInstructions(mode string) (processingInstructions, error) {
-	switch mode {
-	case bookMode:
-		return processingInstructions{
-			CompressorInstruction: s.config.Instructions.Book,
-			FormatterInstruction:  s.config.Instructions.Formatter_book,
-		}, nil
-	case jurisprudenceMode:
-		return processingInstructions{
-			CompressorInstruction: s.config.Instructions.Jurisprudence,
-			FormatterInstruction:  s.config.Instructions.Formatter_jurisprudence,
-		}, nil
-	case articleMode:
-		return processingInstructions{
-			CompressorInstruction: s.config.Instructions.Article,
-			FormatterInstruction:  s.config.Instructions.Formatter_article,
-		}, nil
-	case docMode:
-		return processingInstructions{
-			CompressorInstruction: s.config.Instructions.Doc,
-			FormatterInstruction:  s.config.Instructions.Formatter_doc,
-		}, nil
-	case defaultMode:
-		return processingInstructions{
-			CompressorInstruction: s.config.Instructions.Def,
-			FormatterInstruction:  s.config.Instructions.Formatter_def,
-		}, nil
-	default:
-		return processingInstructions{}, errors.New("unsupported mode")
+	return doc, nil
+}
+
+// getInstructions resolves the mode visible to the user, the layout of the requested format,
+// the requested length and target language, and fills in the default temperatures and prompt variables.
+func (s *file) getInstructions(ctx context.Context, opts models.SummaryOptions, userID int) (ResolvedMode, processingInstructions, error) {
+	spec, err := length.Parse(opts.Length)
+	if err != nil {
+		return ResolvedMode{}, processingInstructions{}, err
+	}
+	target, err := lang.Parse(opts.TargetLanguage)
+	if err != nil {
+		return ResolvedMode{}, processingInstructions{}, err
+	}
+	mode, err := s.modes.Resolve(ctx, opts.Mode, userID)
+	if err != nil {
+		return ResolvedMode{}, processingInstructions{}, err
+	}
+
+	instructions := processingInstructions{
+		Compressor:     mode.Compressor,
+		Formatter:      mode.Formatter,
+		Layout:         s.layouts[formatOrDefault(opts.Format)],
+		Vars:           prompt.Vars{MaxSentences: s.config.Summarizer.MaxSentences},
+		CompressTemp:   s.config.Summarizer.CompressTemp,
+		FormatTemp:     s.config.Summarizer.FormatTemp,
+		Length:         spec,
+		TargetLanguage: target,
+		Citations:      opts.Citations && opts.Output != models.OutputJSON,
+	}
+	if mode.CompressTemp != nil {
+		instructions.CompressTemp = *mode.CompressTemp
 	}
+	if mode.FormatTemp != nil {
+		instructions.FormatTemp = *mode.FormatTemp
+	}
+	return mode, instructions, nil
+}
+
+// spliter cuts the text into chunks of Summarizer.ChunkSize tokens on sentence and paragraph
+// boundaries, repeating Summarizer.ChunkOverlap tokens of context between neighbouring chunks.
+// language is the detected language of the text, empty when unknown. Chunks keep their offsets
+// in the text, which citations point to.
+func (s *file) spliter(text, language string, size splitter.SizeFunc) []splitter.Chunk {
+	return splitter.Split(text, splitter.Options{
+		ChunkSize: s.config.Summarizer.ChunkSize,
+		Overlap:   s.config.Summarizer.ChunkOverlap,
+		Language:  language,
+		Size:      size,
+	})
 }
 
-func (s *file) spliter(text string) []string {
-	parts := strings.Fields(text)
-	var result []string
+// tokenSizer measures text in model tokens. The tokenizer is asked once for a sample of the
+// document and the ratio of tokens to characters is used for everything else, which keeps
+// splitting to a single request. Without the tokenizer it falls back to an estimate.
+func (s *file) tokenSizer(ctx context.Context, text string) splitter.SizeFunc {
+	const op = "service.tokenSizer"
 
-	chunkSize := s.config.Summarizer.ChunkSize
-	for i := 0; i < len(parts); i += chunkSize {
-		end := i + chunkSize
-		if end > len(parts) {
-			end = len(parts)
-		}
-		chunk := strings.Join(parts[i:end], " ")
-		result = append(result, chunk)
+	sample := text
+	if len(sample) > tokenSampleSize {
+		sample = strings.ToValidUTF8(sample[:tokenSampleSize], "")
+	}
+	runes := utf8.RuneCountInString(sample)
+	if runes == 0 {
+		return splitter.EstimateTokens
+	}
+
+	tokens, err := s.ai.CountTokens(ctx, sample)
+	if err != nil || tokens == 0 {
+		s.log.Warn(op, "message", "token count unavailable, using estimate", "error", err)
+		return splitter.EstimateTokens
 	}
 
-	return result
+	ratio := float64(tokens) / float64(runes)
+	return func(text string) int {
+		return int(math.Ceil(float64(utf8.RuneCountInString(text)) * ratio))
+	}
 }
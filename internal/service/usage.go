@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+	"todoai/internal/config"
+	"todoai/internal/gateway/ai"
+	"todoai/internal/models"
+)
+
+var ErrQuotaExceeded = errors.New("token quota exceeded")
+
+type UsageService interface {
+	Record(ctx context.Context, usage models.TokenUsage, userID int) error
+	// Check returns ErrQuotaExceeded when the user has used up the daily or the monthly quota.
+	Check(ctx context.Context, userID int) error
+	Get(ctx context.Context, userID int) (models.UsageReport, error)
+}
+
+type usageService struct {
+	tm  *TransactionManager
+	log *slog.Logger
+	cfg *config.Config
+	now func() time.Time
+}
+
+func NewUsageService(tm *TransactionManager, log *slog.Logger, cfg *config.Config) *usageService {
+	return &usageService{tm: tm, log: log, cfg: cfg, now: time.Now}
+}
+
+func (s *usageService) Record(ctx context.Context, usage models.TokenUsage, userID int) error {
+	const op = "service.usageService.Record"
+	if usage.Total() == 0 {
+		return nil
+	}
+
+	repo := s.tm.NewUsageRepo()
+	if err := repo.Record(ctx, usage, userID); err != nil {
+		s.log.Error(op, "error", err)
+		return err
+	}
+	return nil
+}
+
+// Check treats all anonymous callers as one user limited by Anonymous.DailyTokens.
+func (s *usageService) Check(ctx context.Context, userID int) error {
+	report, err := s.Get(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if exhausted(report.Daily) || exhausted(report.Monthly) {
+		return ErrQuotaExceeded
+	}
+	return nil
+}
+
+func (s *usageService) Get(ctx context.Context, userID int) (models.UsageReport, error) {
+	const op = "service.usageService.Get"
+
+	now := s.now().UTC()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	dailyLimit, monthlyLimit := s.cfg.Quotas.DailyTokens, s.cfg.Quotas.MonthlyTokens
+	if userID == 0 {
+		dailyLimit, monthlyLimit = s.cfg.Anonymous.DailyTokens, 0
+	}
+
+	daily, err := s.period(ctx, day, dailyLimit, userID)
+	if err != nil {
+		s.log.Error(op, "error", err)
+		return models.UsageReport{}, err
+	}
+	monthly, err := s.period(ctx, month, monthlyLimit, userID)
+	if err != nil {
+		s.log.Error(op, "error", err)
+		return models.UsageReport{}, err
+	}
+
+	return models.UsageReport{Daily: daily, Monthly: monthly}, nil
+}
+
+func (s *usageService) period(ctx context.Context, since time.Time, limit int, userID int) (models.UsagePeriod, error) {
+	repo := s.tm.NewUsageRepo()
+	usage, err := repo.Sum(ctx, since, userID)
+	if err != nil {
+		return models.UsagePeriod{}, err
+	}
+
+	return models.UsagePeriod{
+		Since:        since,
+		PromptTokens: usage.PromptTokens,
+		OutputTokens: usage.OutputTokens,
+		TotalTokens:  usage.Total(),
+		Limit:        limit,
+	}, nil
+}
+
+func exhausted(period models.UsagePeriod) bool {
+	return period.Limit > 0 && period.TotalTokens >= period.Limit
+}
+
+// recordUsage charges the tokens of a model call to the user. The tokens are spent even when
+// the caller has gone away, so they are recorded regardless of ctx being cancelled. A failed
+// write does not fail the call that spent them but is logged with what was lost.
+func recordUsage(ctx context.Context, usage UsageService, log *slog.Logger, op string, result ai.Result, userID int) {
+	tokens := models.TokenUsage{
+		PromptTokens: result.Usage.PromptTokens,
+		OutputTokens: result.Usage.OutputTokens,
+	}
+	if err := usage.Record(context.WithoutCancel(ctx), tokens, userID); err != nil {
+		log.Error(op, "message", "token usage not recorded", "user_id", userID, "tokens", tokens.Total(), "error", err)
+	}
+}
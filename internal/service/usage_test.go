@@ -0,0 +1,55 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"todoai/internal/gateway/ai"
+	"todoai/internal/models"
+
+	"github.com/stretchr/testify/require"
+)
+
+// recordingUsage records token usage and fails with err when it is set.
+type recordingUsage struct {
+	UsageService
+	recorded []models.TokenUsage
+	err      error
+}
+
+func (u *recordingUsage) Record(ctx context.Context, usage models.TokenUsage, userID int) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if u.err != nil {
+		return u.err
+	}
+	u.recorded = append(u.recorded, usage)
+	return nil
+}
+
+func TestRecordUsage(t *testing.T) {
+	result := ai.Result{Usage: ai.Usage{PromptTokens: 10, OutputTokens: 3}}
+
+	t.Run("cancelled caller", func(t *testing.T) {
+		usage := &recordingUsage{}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		recordUsage(ctx, usage, slog.New(slog.NewTextHandler(io.Discard, nil)), "test", result, 1)
+		require.Equal(t, []models.TokenUsage{{PromptTokens: 10, OutputTokens: 3}}, usage.recorded)
+	})
+
+	t.Run("failed write is logged", func(t *testing.T) {
+		var logs bytes.Buffer
+		usage := &recordingUsage{err: errors.New("database is down")}
+
+		recordUsage(context.Background(), usage, slog.New(slog.NewTextHandler(&logs, nil)), "test", result, 1)
+		require.Contains(t, logs.String(), "token usage not recorded")
+		require.Contains(t, logs.String(), "tokens=13")
+		require.Contains(t, logs.String(), "database is down")
+	})
+}
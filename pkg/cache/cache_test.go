@@ -0,0 +1,35 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLRU(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	c := NewLRU(2)
+	c.now = func() time.Time { return now }
+
+	require.NoError(t, c.Set(ctx, "a", []byte("1"), time.Minute))
+	require.NoError(t, c.Set(ctx, "b", []byte("2"), time.Minute))
+
+	value, ok, err := c.Get(ctx, "a")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, []byte("1"), value)
+
+	// "b" is the least recently used entry now.
+	require.NoError(t, c.Set(ctx, "c", []byte("3"), time.Minute))
+	require.Equal(t, 2, c.Len())
+	_, ok, _ = c.Get(ctx, "b")
+	require.False(t, ok)
+
+	now = now.Add(time.Minute)
+	_, ok, _ = c.Get(ctx, "a")
+	require.False(t, ok)
+	require.Equal(t, 1, c.Len())
+}
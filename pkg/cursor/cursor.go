@@ -0,0 +1,30 @@
+package cursor
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+var ErrInvalid = errors.New("invalid cursor")
+
+// Encode turns the position of the last row of a page into an opaque cursor.
+func Encode(position any) (string, error) {
+	data, err := json.Marshal(position)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// Decode reads a cursor made by Encode into position.
+func Decode(cursor string, position any) error {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return ErrInvalid
+	}
+	if err := json.Unmarshal(data, position); err != nil {
+		return ErrInvalid
+	}
+	return nil
+}
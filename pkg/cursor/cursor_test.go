@@ -0,0 +1,39 @@
+package cursor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type position struct {
+	Value string `json:"v"`
+	ID    int    `json:"id"`
+}
+
+func TestRoundTrip(t *testing.T) {
+	encoded, err := Encode(position{Value: "2026-10-17T10:00:00.123456Z", ID: 42})
+	require.NoError(t, err)
+
+	var decoded position
+	require.NoError(t, Decode(encoded, &decoded))
+	require.Equal(t, position{Value: "2026-10-17T10:00:00.123456Z", ID: 42}, decoded)
+}
+
+func TestDecode_Invalid(t *testing.T) {
+	testTable := []struct {
+		name   string
+		cursor string
+	}{
+		{name: "not base64", cursor: "!!!"},
+		{name: "not JSON", cursor: "bm90IGpzb24"},
+		{name: "wrong shape", cursor: "WzEsMl0"},
+	}
+
+	for _, tt := range testTable {
+		t.Run(tt.name, func(t *testing.T) {
+			var decoded position
+			require.ErrorIs(t, Decode(tt.cursor, &decoded), ErrInvalid)
+		})
+	}
+}
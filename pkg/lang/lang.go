@@ -0,0 +1,127 @@
+package lang
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+var ErrUnsupported = errors.New("unsupported language")
+
+const (
+	// sampleSize is how many bytes of a text Detect looks at.
+	sampleSize = 16 << 10
+	// minLetters is how many letters a text needs for its language to be detected.
+	minLetters = 20
+	// minStopwords is how many common words of a Latin-script language a text needs.
+	minStopwords = 2
+)
+
+// Names are the supported languages by ISO 639-1 code.
+var Names = map[string]string{
+	"en": "English",
+	"ru": "Russian",
+	"uk": "Ukrainian",
+	"es": "Spanish",
+	"de": "German",
+	"fr": "French",
+	"it": "Italian",
+	"pt": "Portuguese",
+}
+
+// latin are the Latin-script languages told apart by their most common words,
+// in the order ties are broken.
+var latin = []string{"en", "es", "de", "fr", "it", "pt"}
+
+var stopwords = map[string]map[string]struct{}{
+	"en": set("the", "and", "of", "to", "is", "in", "that", "it", "for", "with", "was", "are", "this", "be", "on", "as", "by", "not", "have", "from", "which"),
+	"es": set("el", "la", "los", "las", "de", "que", "y", "en", "un", "una", "es", "por", "con", "para", "del", "se", "no", "al", "como", "más"),
+	"de": set("der", "die", "das", "und", "ist", "nicht", "ein", "eine", "zu", "den", "von", "mit", "sich", "des", "auf", "für", "dem", "auch", "es", "im"),
+	"fr": set("le", "la", "les", "de", "des", "et", "est", "un", "une", "que", "en", "du", "dans", "pour", "pas", "au", "qui", "sur", "avec", "ne"),
+	"it": set("il", "la", "di", "che", "e", "è", "un", "una", "per", "non", "del", "della", "le", "con", "sono", "gli", "si", "da", "al", "nel"),
+	"pt": set("o", "a", "os", "as", "de", "que", "e", "do", "da", "em", "um", "uma", "não", "para", "com", "é", "no", "na", "dos", "por"),
+}
+
+// Name returns the English name of the language with the code, or an empty string.
+func Name(code string) string {
+	return Names[code]
+}
+
+// Parse reads a language given by its ISO 639-1 code or English name and returns the code.
+// An empty string is returned as is.
+func Parse(s string) (string, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return "", nil
+	}
+	if _, ok := Names[s]; ok {
+		return s, nil
+	}
+	for code, name := range Names {
+		if strings.ToLower(name) == s {
+			return code, nil
+		}
+	}
+	return "", ErrUnsupported
+}
+
+// Detect returns the ISO 639-1 code of the language of the text, or an empty string when
+// the text is too short or in none of the supported languages. Cyrillic texts are Russian
+// or Ukrainian by their distinctive letters; Latin-script texts are told apart by their
+// most common words.
+func Detect(text string) string {
+	if len(text) > sampleSize {
+		text = text[:sampleSize]
+	}
+
+	var cyrillic, latinLetters, ru, uk int
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+			switch r {
+			case 'ы', 'э', 'ъ', 'ё':
+				ru++
+			case 'і', 'ї', 'є', 'ґ':
+				uk++
+			}
+		case unicode.Is(unicode.Latin, r):
+			latinLetters++
+		}
+	}
+	if cyrillic+latinLetters < minLetters {
+		return ""
+	}
+	if cyrillic > latinLetters {
+		if uk > ru {
+			return "uk"
+		}
+		return "ru"
+	}
+
+	scores := make(map[string]int, len(latin))
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}) {
+		for _, code := range latin {
+			if _, ok := stopwords[code][word]; ok {
+				scores[code]++
+			}
+		}
+	}
+	best := ""
+	for _, code := range latin {
+		if scores[code] >= minStopwords && scores[code] > scores[best] {
+			best = code
+		}
+	}
+	return best
+}
+
+func set(words ...string) map[string]struct{} {
+	m := make(map[string]struct{}, len(words))
+	for _, w := range words {
+		m[w] = struct{}{}
+	}
+	return m
+}
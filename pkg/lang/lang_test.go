@@ -0,0 +1,61 @@
+package lang
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetect(t *testing.T) {
+	testTable := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{name: "english", text: "The court held that the contract was void, and the claim for damages is dismissed.", expected: "en"},
+		{name: "russian", text: "Суд установил, что договор является ничтожным, и отказал в удовлетворении иска.", expected: "ru"},
+		{name: "ukrainian", text: "Суд встановив, що договір є нікчемним, і відмовив у задоволенні позову.", expected: "uk"},
+		{name: "spanish", text: "El tribunal declaró que el contrato es nulo y rechazó la demanda por daños.", expected: "es"},
+		{name: "german", text: "Das Gericht stellte fest, dass der Vertrag nichtig ist, und wies die Klage ab.", expected: "de"},
+		{name: "french", text: "Le tribunal a jugé que le contrat est nul et a rejeté la demande des parties.", expected: "fr"},
+		{name: "italian", text: "Il tribunale ha stabilito che il contratto è nullo e ha respinto la domanda di risarcimento.", expected: "it"},
+		{name: "portuguese", text: "O tribunal decidiu que o contrato é nulo e rejeitou o pedido de indenização dos autores.", expected: "pt"},
+		{name: "too short", text: "Hello", expected: ""},
+		{name: "no common words", text: "Lorem ipsum dolor sit amet consectetur adipiscing elit", expected: ""},
+		{name: "numbers only", text: "12345 67890 12345 67890 12345 67890", expected: ""},
+	}
+
+	for _, tt := range testTable {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, Detect(tt.text))
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	testTable := []struct {
+		name     string
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{name: "empty", input: "", expected: ""},
+		{name: "code", input: "ru", expected: "ru"},
+		{name: "upper case code", input: " EN ", expected: "en"},
+		{name: "name", input: "Russian", expected: "ru"},
+		{name: "unknown code", input: "xx", wantErr: true},
+		{name: "unknown name", input: "Klingon", wantErr: true},
+	}
+
+	for _, tt := range testTable {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := Parse(tt.input)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrUnsupported)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, code)
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package length
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var ErrInvalid = errors.New("length must be short, medium, long, a word budget like 300w, a sentence budget like 12s or a ratio like 0.1 or 10%")
+
+const (
+	minWords     = 10
+	maxWords     = 5000
+	maxSentences = 200
+	// minRatioWords keeps a ratio of a short text from asking for a few words.
+	minRatioWords = 30
+)
+
+// Presets are the named lengths in sentences.
+var Presets = map[string]int{
+	"short":  5,
+	"medium": 10,
+	"long":   20,
+}
+
+// Spec is a requested summary length: a word budget, a sentence budget or a share of the
+// source length. The zero Spec means no budget.
+type Spec struct {
+	Words     int
+	Sentences int
+	Ratio     float64
+}
+
+// Parse reads a length parameter. An empty string is the zero Spec.
+func Parse(s string) (Spec, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return Spec{}, nil
+	}
+	if sentences, ok := Presets[s]; ok {
+		return Spec{Sentences: sentences}, nil
+	}
+
+	if number, ok := strings.CutSuffix(s, "%"); ok {
+		percent, err := strconv.ParseFloat(strings.TrimSpace(number), 64)
+		if err != nil || percent <= 0 || percent > 100 {
+			return Spec{}, ErrInvalid
+		}
+		return Spec{Ratio: percent / 100}, nil
+	}
+	if ratio, err := strconv.ParseFloat(s, 64); err == nil {
+		if ratio <= 0 || ratio > 1 {
+			return Spec{}, ErrInvalid
+		}
+		return Spec{Ratio: ratio}, nil
+	}
+
+	i := strings.IndexFunc(s, func(r rune) bool { return !unicode.IsDigit(r) })
+	if i <= 0 {
+		return Spec{}, ErrInvalid
+	}
+	n, err := strconv.Atoi(s[:i])
+	if err != nil {
+		return Spec{}, ErrInvalid
+	}
+	switch strings.TrimSpace(s[i:]) {
+	case "w", "word", "words":
+		if n < minWords || n > maxWords {
+			return Spec{}, ErrInvalid
+		}
+		return Spec{Words: n}, nil
+	case "s", "sentence", "sentences":
+		if n < 1 || n > maxSentences {
+			return Spec{}, ErrInvalid
+		}
+		return Spec{Sentences: n}, nil
+	default:
+		return Spec{}, ErrInvalid
+	}
+}
+
+// IsZero reports whether the Spec sets no budget.
+func (s Spec) IsZero() bool {
+	return s == Spec{}
+}
+
+// Budget is the size a summary must fit in, in words or in sentences.
+// The zero Budget means no limit.
+type Budget struct {
+	Words     int
+	Sentences int
+}
+
+// Budget resolves the Spec for a source of sourceWords words.
+func (s Spec) Budget(sourceWords int) Budget {
+	if s.Ratio > 0 {
+		words := int(math.Round(s.Ratio * float64(sourceWords)))
+		return Budget{Words: min(max(words, minRatioWords), maxWords)}
+	}
+	return Budget{Words: s.Words, Sentences: s.Sentences}
+}
+
+func (b Budget) IsZero() bool {
+	return b == Budget{}
+}
+
+// Share is the budget of one of count parts that are summarized separately and then joined.
+// Parts get twice their even share, since the final pass shortens them again, but never
+// more than the whole budget nor less than a couple of sentences.
+func (b Budget) Share(count int) Budget {
+	if count <= 1 || b.IsZero() {
+		return b
+	}
+	share := func(total, floor int) int {
+		if total == 0 {
+			return 0
+		}
+		return min(total, max(floor, int(math.Ceil(float64(2*total)/float64(count)))))
+	}
+	return Budget{Words: share(b.Words, minWords), Sentences: share(b.Sentences, 2)}
+}
+
+// Exceeds reports whether text is longer than the budget by more than the given factor.
+func (b Budget) Exceeds(text string, factor float64) bool {
+	if b.Words > 0 && float64(Words(text)) > float64(b.Words)*factor {
+		return true
+	}
+	return b.Sentences > 0 && float64(Sentences(text)) > float64(b.Sentences)*factor
+}
+
+// Words counts whitespace-separated words.
+func Words(text string) int {
+	return len(strings.Fields(text))
+}
+
+// Sentences counts sentence-ending punctuation followed by a space or the end of a line.
+// A line without one, such as a list item, counts as a sentence.
+func Sentences(text string) int {
+	count := 0
+	for _, line := range strings.Split(text, "\n") {
+		runes := []rune(strings.TrimSpace(line))
+		if len(runes) == 0 {
+			continue
+		}
+		ends := 0
+		for i, r := range runes {
+			if strings.ContainsRune(".!?…", r) && (i+1 == len(runes) || unicode.IsSpace(runes[i+1])) {
+				ends++
+			}
+		}
+		count += max(ends, 1)
+	}
+	return count
+}
@@ -0,0 +1,64 @@
+package length
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	testTable := []struct {
+		name     string
+		input    string
+		expected Spec
+		wantErr  bool
+	}{
+		{name: "empty", input: "", expected: Spec{}},
+		{name: "preset", input: "Short", expected: Spec{Sentences: 5}},
+		{name: "words", input: "300w", expected: Spec{Words: 300}},
+		{name: "words spelled out", input: "300 words", expected: Spec{Words: 300}},
+		{name: "sentences", input: "12s", expected: Spec{Sentences: 12}},
+		{name: "ratio", input: "0.1", expected: Spec{Ratio: 0.1}},
+		{name: "percent", input: "25%", expected: Spec{Ratio: 0.25}},
+		{name: "ratio above one", input: "1.5", wantErr: true},
+		{name: "too few words", input: "5w", wantErr: true},
+		{name: "zero sentences", input: "0s", wantErr: true},
+		{name: "unknown unit", input: "3p", wantErr: true},
+		{name: "unknown preset", input: "tiny", wantErr: true},
+	}
+
+	for _, tt := range testTable {
+		t.Run(tt.name, func(t *testing.T) {
+			spec, err := Parse(tt.input)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrInvalid)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, spec)
+		})
+	}
+}
+
+func TestSpec_Budget(t *testing.T) {
+	require.Equal(t, Budget{Words: 500}, Spec{Ratio: 0.1}.Budget(5000))
+	require.Equal(t, Budget{Words: 30}, Spec{Ratio: 0.1}.Budget(100))
+	require.Equal(t, Budget{Sentences: 12}, Spec{Sentences: 12}.Budget(5000))
+	require.True(t, Spec{}.Budget(5000).IsZero())
+}
+
+func TestBudget_Share(t *testing.T) {
+	require.Equal(t, Budget{Sentences: 10}, Budget{Sentences: 10}.Share(1))
+	require.Equal(t, Budget{Sentences: 5}, Budget{Sentences: 10}.Share(4))
+	require.Equal(t, Budget{Sentences: 2}, Budget{Sentences: 10}.Share(100))
+	require.Equal(t, Budget{Words: 300}, Budget{Words: 300}.Share(2))
+}
+
+func TestBudget_Exceeds(t *testing.T) {
+	text := "One. Two! Three? Four...\n- a list item\n- another one"
+	require.Equal(t, 6, Sentences(text))
+	require.False(t, Budget{Sentences: 4}.Exceeds(text, 1.5))
+	require.True(t, Budget{Sentences: 3}.Exceeds(text, 1.5))
+	require.True(t, Budget{Words: 5}.Exceeds(text, 1.5))
+	require.False(t, Budget{}.Exceeds(text, 1.5))
+}
@@ -0,0 +1,132 @@
+package markdown
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	scriptTag   = regexp.MustCompile(`(?i)<script[^>]*>.*?</script>|<style[^>]*>.*?</style>`)
+	htmlTag     = regexp.MustCompile(`</?[a-zA-Z][^<>]*>`)
+	inlineLink  = regexp.MustCompile(`\[([^\]]*)\]\(((?:[^()]|\([^()]*\))*)\)`)
+	linkDef     = regexp.MustCompile(`^ {0,3}\[[^\]]+\]:\s*(\S+)`)
+	bulletItem  = regexp.MustCompile(`^(\s*)(?:[*+•‣◦]|-)\s+(.*)$`)
+	orderedItem = regexp.MustCompile(`^(\s*)\d+[.)]\s+(.*)$`)
+	heading     = regexp.MustCompile(`^(#{1,6})\s*(.*?)\s*#*$`)
+)
+
+// Sanitize cleans up Markdown written by a model: it unwraps an answer fenced as a whole,
+// removes HTML tags, scripts, and inline links and link reference definitions with script or
+// data URLs, even when the scheme is written with entities. It writes every bullet as "- ",
+// puts a space after heading marks and collapses runs of blank lines. Code blocks are kept
+// as they are.
+func Sanitize(md string) string {
+	md = strings.ReplaceAll(md, "\r\n", "\n")
+	md = unwrapFence(strings.TrimSpace(md))
+
+	var out []string
+	inCode := false
+	blank := false
+	for _, line := range strings.Split(md, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCode = !inCode
+			out = append(out, strings.TrimRight(line, " \t"))
+			blank = false
+			continue
+		}
+		if inCode {
+			out = append(out, line)
+			continue
+		}
+
+		line = strings.TrimRight(line, " \t")
+		line = scriptTag.ReplaceAllString(line, "")
+		line = htmlTag.ReplaceAllString(line, "")
+		if m := linkDef.FindStringSubmatch(line); m != nil && unsafeURL(m[1]) {
+			continue
+		}
+		line = inlineLink.ReplaceAllStringFunc(line, func(link string) string {
+			m := inlineLink.FindStringSubmatch(link)
+			if unsafeURL(m[2]) {
+				return m[1]
+			}
+			return link
+		})
+		if m := bulletItem.FindStringSubmatch(line); m != nil {
+			line = m[1] + "- " + m[2]
+		} else if m := heading.FindStringSubmatch(line); m != nil {
+			line = m[1] + " " + m[2]
+		}
+
+		if strings.TrimSpace(line) == "" {
+			if !blank && len(out) > 0 {
+				out = append(out, "")
+			}
+			blank = true
+			continue
+		}
+		blank = false
+		out = append(out, line)
+	}
+	return strings.TrimSpace(strings.Join(out, "\n"))
+}
+
+// unsafeURL reports whether a link destination uses a script or data URL. Entities are decoded
+// and whitespace and control characters dropped first, as browsers ignore them in a scheme.
+func unsafeURL(dest string) bool {
+	dest = html.UnescapeString(strings.TrimPrefix(strings.TrimSpace(dest), "<"))
+	dest = strings.Map(func(r rune) rune {
+		if r <= ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, dest)
+	dest = strings.ToLower(dest)
+	for _, scheme := range []string{"javascript:", "vbscript:", "data:"} {
+		if strings.HasPrefix(dest, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// Bullets turns sanitized Markdown into a bullet list: headings, numbered items and
+// paragraphs become "- " items, nested items keep their indentation.
+func Bullets(md string) string {
+	var out []string
+	for _, line := range strings.Split(md, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		switch {
+		case bulletItem.MatchString(line):
+			out = append(out, line)
+		case orderedItem.MatchString(line):
+			m := orderedItem.FindStringSubmatch(line)
+			out = append(out, m[1]+"- "+m[2])
+		case heading.MatchString(line):
+			out = append(out, "- "+heading.FindStringSubmatch(line)[2])
+		default:
+			out = append(out, "- "+strings.TrimSpace(line))
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+func unwrapFence(md string) string {
+	if !strings.HasPrefix(md, "```") || !strings.HasSuffix(md, "```") || len(md) < 6 {
+		return md
+	}
+	body := strings.TrimSuffix(md, "```")
+	newline := strings.IndexByte(body, '\n')
+	if newline < 0 {
+		return md
+	}
+	body = body[newline+1:]
+	// A fence inside means the answer is not a single code block.
+	if strings.Contains(body, "```") {
+		return md
+	}
+	return strings.TrimSpace(body)
+}
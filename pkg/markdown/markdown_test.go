@@ -0,0 +1,97 @@
+package markdown
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSanitize(t *testing.T) {
+	testTable := []struct {
+		name     string
+		md       string
+		expected string
+	}{
+		{
+			name:     "unwraps a fenced answer",
+			md:       "```markdown\n## Title\n\nText.\n```",
+			expected: "## Title\n\nText.",
+		},
+		{
+			name:     "removes html and script links",
+			md:       "Text <b>bold</b><script>alert(1)</script> [click](javascript:alert(1)) [ok](https://example.com)",
+			expected: "Text bold click [ok](https://example.com)",
+		},
+		{
+			name:     "removes script links with encoded schemes",
+			md:       "[a](jav&#x61;script:alert(1)) [b]( JavaScript:alert(1)) [c](java\tscript:x) [d](data:text/html,x)",
+			expected: "a b c d",
+		},
+		{
+			name:     "removes unsafe reference definitions",
+			md:       "See [x] and [y].\n\n[x]: javascript:alert(1)\n[y]: https://example.com \"Example\"",
+			expected: "See [x] and [y].\n\n[y]: https://example.com \"Example\"",
+		},
+		{
+			name:     "normalizes bullets and headings",
+			md:       "##Title\n* one\n  + two\n• three",
+			expected: "## Title\n- one\n  - two\n- three",
+		},
+		{
+			name:     "collapses blank lines and trims",
+			md:       "\r\n\r\nFirst.   \n\n\n\nSecond.\n\n",
+			expected: "First.\n\nSecond.",
+		},
+		{
+			name:     "keeps code blocks",
+			md:       "Run:\n```\n* <b>not a bullet</b>\n```",
+			expected: "Run:\n```\n* <b>not a bullet</b>\n```",
+		},
+	}
+
+	for _, tt := range testTable {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, Sanitize(tt.md))
+		})
+	}
+}
+
+func TestBullets(t *testing.T) {
+	md := "## Topic\nA paragraph.\n\n1. first\n  - nested"
+	require.Equal(t, "- Topic\n- A paragraph.\n- first\n  - nested", Bullets(md))
+}
+
+func TestParseOutline(t *testing.T) {
+	md := `# Contract
+## Parties
+- Seller
+- Buyer
+  - represented by an agent
+## Terms
+Payment within 30 days.
+# Annex`
+
+	expected := []Node{
+		{Title: "Contract", Children: []Node{
+			{Title: "Parties", Children: []Node{
+				{Title: "Seller"},
+				{Title: "Buyer", Children: []Node{{Title: "represented by an agent"}}},
+			}},
+			{Title: "Terms", Children: []Node{{Title: "Payment within 30 days."}}},
+		}},
+		{Title: "Annex"},
+	}
+	outline := ParseOutline(md)
+	require.Equal(t, expected, outline)
+
+	require.Equal(t, outline, ParseOutline(RenderOutline(outline)))
+}
+
+func TestParseOutline_BulletsOnly(t *testing.T) {
+	outline := ParseOutline("- one\n    - two\n- three")
+	require.Equal(t, []Node{
+		{Title: "one", Children: []Node{{Title: "two"}}},
+		{Title: "three"},
+	}, outline)
+	require.Equal(t, "# one\n\n## two\n\n# three", RenderOutline(outline))
+}
@@ -0,0 +1,97 @@
+package markdown
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Node is an entry of an outline with the entries nested under it.
+type Node struct {
+	Title    string `json:"title"`
+	Children []Node `json:"children,omitempty"`
+}
+
+const (
+	// bulletLevel is the level of an unindented bullet, below every heading.
+	bulletLevel = 7
+	// textLevel is the level of a plain line, which never has children.
+	textLevel = 1 << 10
+)
+
+type treeNode struct {
+	level    int
+	title    string
+	children []*treeNode
+}
+
+// ParseOutline builds the tree of headings and bullets of sanitized Markdown. A heading
+// holds everything up to the next heading of the same or a higher level, a bullet holds
+// the bullets indented under it, and plain lines become leaves of the entry above them.
+func ParseOutline(md string) []Node {
+	root := &treeNode{level: 0}
+	stack := []*treeNode{root}
+	for _, line := range strings.Split(md, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		node := &treeNode{level: textLevel, title: strings.TrimSpace(line)}
+		if m := heading.FindStringSubmatch(line); m != nil {
+			node.level, node.title = len(m[1]), m[2]
+		} else if m := bulletItem.FindStringSubmatch(line); m != nil {
+			node.level, node.title = bulletLevel+indent(m[1]), m[2]
+		} else if m := orderedItem.FindStringSubmatch(line); m != nil {
+			node.level, node.title = bulletLevel+indent(m[1]), m[2]
+		}
+		if node.title == "" {
+			continue
+		}
+
+		for len(stack) > 1 && stack[len(stack)-1].level >= node.level {
+			stack = stack[:len(stack)-1]
+		}
+		parent := stack[len(stack)-1]
+		parent.children = append(parent.children, node)
+		stack = append(stack, node)
+	}
+	return nodes(root.children)
+}
+
+// RenderOutline writes an outline as Markdown: the first two levels as headings and the
+// deeper ones as bullets indented by two spaces. ParseOutline reads it back unchanged.
+func RenderOutline(outline []Node) string {
+	var b strings.Builder
+	render(&b, outline, 0)
+	return strings.TrimSpace(b.String())
+}
+
+func render(b *strings.Builder, outline []Node, depth int) {
+	for _, node := range outline {
+		switch {
+		case depth < 2:
+			if b.Len() > 0 {
+				b.WriteString("\n")
+			}
+			b.WriteString(strings.Repeat("#", depth+1) + " " + node.Title + "\n")
+		default:
+			b.WriteString(strings.Repeat("  ", depth-2) + "- " + node.Title + "\n")
+		}
+		render(b, node.Children, depth+1)
+	}
+}
+
+func nodes(tree []*treeNode) []Node {
+	if len(tree) == 0 {
+		return nil
+	}
+	out := make([]Node, len(tree))
+	for i, node := range tree {
+		out[i] = Node{Title: node.title, Children: nodes(node.children)}
+	}
+	return out
+}
+
+// indent measures leading whitespace in spaces, counting a tab as four.
+func indent(prefix string) int {
+	return utf8.RuneCountInString(strings.ReplaceAll(prefix, "\t", "    "))
+}
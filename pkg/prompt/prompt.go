@@ -0,0 +1,180 @@
+package prompt
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// MaxRendered is the most bytes a template may render.
+const MaxRendered = 32 << 10
+
+// ErrTooLong is returned when a template renders more than MaxRendered bytes.
+var ErrTooLong = errors.New("prompt renders too long")
+
+// userFuncs are the only functions user templates may call. Functions like printf are left out,
+// because they can build a huge string before anything is written. The parser only checks that
+// a name is present, so the values are placeholders.
+var userFuncs = map[string]any{
+	"and": true, "or": true, "not": true,
+	"eq": true, "ne": true, "lt": true, "le": true, "gt": true, "ge": true,
+}
+
+// Vars are the request-level values available to prompt templates.
+type Vars struct {
+	// MaxSentences and MaxWords are how long the answer may be. At most one of them is set;
+	// neither is set for a formatter that may keep the length of its input.
+	MaxSentences int
+	MaxWords     int
+	// Language is the language the answer must be written in. Empty means the language of the input.
+	Language string
+	// SourceLanguage is the detected language of the document when the answer is a translation of it.
+	SourceLanguage string
+	// Citations means the input sentences carry source tags that the answer must keep.
+	Citations bool
+	// ChunkIndex is the 1-based number of the chunk being compressed; zero for the formatter.
+	ChunkIndex int
+	// ChunkCount is how many chunks the document was cut into.
+	ChunkCount int
+	// Filename is the name of the uploaded file; empty for plain text.
+	Filename string
+}
+
+// checkVars are used to execute a template once after parsing, so that unknown variables and
+// missing partials are reported before the template is used.
+var checkVars = []Vars{
+	{},
+	{MaxSentences: 10, Language: "English", SourceLanguage: "Russian", ChunkIndex: 2, ChunkCount: 3, Filename: "document.pdf"},
+	{MaxWords: 300, ChunkIndex: 1, ChunkCount: 1, Citations: true},
+}
+
+// Set holds the shared partials that templates include with {{template "name" .}}.
+type Set struct {
+	base     *template.Template
+	partials map[string]bool
+}
+
+// NewSet parses the partials by name.
+func NewSet(partials map[string]string) (*Set, error) {
+	names := make([]string, 0, len(partials))
+	for name := range partials {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	base := template.New("").Option("missingkey=error")
+	known := make(map[string]bool, len(names))
+	for _, name := range names {
+		if _, err := base.New(name).Parse(partials[name]); err != nil {
+			return nil, fmt.Errorf("partial %s: %w", name, err)
+		}
+		known[name] = true
+	}
+	return &Set{base: base, partials: known}, nil
+}
+
+// Template is a parsed prompt.
+type Template struct {
+	tmpl *template.Template
+}
+
+// Parse parses text with access to the partials of the set and checks that it renders.
+func (s *Set) Parse(name, text string) (*Template, error) {
+	base, err := s.base.Clone()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	tmpl, err := base.New(name).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	t := &Template{tmpl: tmpl}
+	for _, vars := range checkVars {
+		if _, err := t.Render(vars); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return t, nil
+}
+
+// ParseUser parses a template written by a user. Only substitutions, conditions and the partials
+// of the set are allowed: loops, template definitions and functions that build text could make
+// a short template render without end.
+func (s *Set) ParseUser(name, text string) (*Template, error) {
+	trees, err := parse.Parse(name, text, "", "", userFuncs)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	if len(trees) > 1 {
+		return nil, fmt.Errorf("%s: templates may not be defined", name)
+	}
+	if tree := trees[name]; tree != nil {
+		if err := s.checkUser(tree.Root); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return s.Parse(name, text)
+}
+
+func (s *Set) checkUser(node parse.Node) error {
+	switch node := node.(type) {
+	case *parse.ListNode:
+		if node == nil {
+			return nil
+		}
+		for _, n := range node.Nodes {
+			if err := s.checkUser(n); err != nil {
+				return err
+			}
+		}
+	case *parse.TextNode, *parse.ActionNode, *parse.CommentNode:
+	case *parse.IfNode:
+		return s.checkBranch(&node.BranchNode)
+	case *parse.WithNode:
+		return s.checkBranch(&node.BranchNode)
+	case *parse.TemplateNode:
+		if !s.partials[node.Name] {
+			return fmt.Errorf("unknown partial %q", node.Name)
+		}
+	case *parse.RangeNode:
+		return errors.New("range is not allowed")
+	default:
+		return fmt.Errorf("%s is not allowed", node)
+	}
+	return nil
+}
+
+func (s *Set) checkBranch(node *parse.BranchNode) error {
+	if err := s.checkUser(node.List); err != nil {
+		return err
+	}
+	return s.checkUser(node.ElseList)
+}
+
+// limitedWriter fails with ErrTooLong once more than left bytes are written.
+type limitedWriter struct {
+	b    strings.Builder
+	left int
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	if len(p) > w.left {
+		return 0, ErrTooLong
+	}
+	w.left -= len(p)
+	return w.b.Write(p)
+}
+
+// Render executes the template. Surrounding whitespace is trimmed, so partials may end
+// with a newline. Rendering stops with ErrTooLong after MaxRendered bytes.
+func (t *Template) Render(vars Vars) (string, error) {
+	w := &limitedWriter{left: MaxRendered}
+	if err := t.tmpl.Execute(w, vars); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(w.b.String()), nil
+}
@@ -0,0 +1,120 @@
+package prompt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplate_Render(t *testing.T) {
+	set, err := NewSet(map[string]string{
+		"language": `{{if .Language}}Answer in {{.Language}}.{{else}}Answer in the language of the input.{{end}}`,
+		"part":     `{{if gt .ChunkCount 1}}This is part {{.ChunkIndex}} of {{.ChunkCount}}.{{end}}`,
+	})
+	require.NoError(t, err)
+
+	tmpl, err := set.Parse("compressor", `
+Summarize in at most {{.MaxSentences}} sentences. {{template "language" .}} {{template "part" .}}
+`)
+	require.NoError(t, err)
+
+	testTable := []struct {
+		name     string
+		vars     Vars
+		expected string
+	}{
+		{
+			name:     "single chunk in the input language",
+			vars:     Vars{MaxSentences: 5, ChunkIndex: 1, ChunkCount: 1},
+			expected: "Summarize in at most 5 sentences. Answer in the language of the input.",
+		},
+		{
+			name:     "part of a document in a given language",
+			vars:     Vars{MaxSentences: 10, Language: "Spanish", ChunkIndex: 2, ChunkCount: 4},
+			expected: "Summarize in at most 10 sentences. Answer in Spanish. This is part 2 of 4.",
+		},
+	}
+
+	for _, tt := range testTable {
+		t.Run(tt.name, func(t *testing.T) {
+			text, err := tmpl.Render(tt.vars)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, text)
+		})
+	}
+}
+
+func TestSet_Parse(t *testing.T) {
+	set, err := NewSet(map[string]string{"language": `Answer in {{.Language}}.`})
+	require.NoError(t, err)
+
+	testTable := []struct {
+		name    string
+		text    string
+		wantErr bool
+	}{
+		{name: "plain text", text: "Summarize the text."},
+		{name: "partial", text: `Summarize the text. {{template "language" .}}`},
+		{name: "syntax error", text: "Summarize {{.MaxSentences", wantErr: true},
+		{name: "unknown variable", text: "Summarize {{.Audience}}", wantErr: true},
+		{name: "missing partial", text: `{{template "audience" .}}`, wantErr: true},
+	}
+
+	for _, tt := range testTable {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := set.Parse("compressor", tt.text)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestNewSet_InvalidPartial(t *testing.T) {
+	_, err := NewSet(map[string]string{"language": `{{if .Language}}`})
+	require.Error(t, err)
+}
+
+func TestSet_ParseUser(t *testing.T) {
+	set, err := NewSet(map[string]string{"language": `Answer in {{.Language}}.`})
+	require.NoError(t, err)
+
+	testTable := []struct {
+		name    string
+		text    string
+		wantErr bool
+	}{
+		{name: "plain text", text: "Summarize the text."},
+		{name: "variable", text: "Summarize in {{.MaxSentences}} sentences."},
+		{name: "condition", text: `{{if gt .ChunkCount 1}}Part {{.ChunkIndex}}.{{else}}Whole text.{{end}}`},
+		{name: "partial", text: `Summarize the text. {{template "language" .}}`},
+		{name: "range", text: `{{range 1000000000}}xxxxxxxxxx{{end}}`, wantErr: true},
+		{name: "range in condition", text: `{{if .Citations}}{{range 10}}x{{end}}{{end}}`, wantErr: true},
+		{name: "define", text: `{{define "x"}}xx{{end}}{{template "x" .}}`, wantErr: true},
+		{name: "block", text: `{{block "x" .}}xx{{end}}`, wantErr: true},
+		{name: "redefined partial", text: `{{define "language"}}xx{{end}}`, wantErr: true},
+		{name: "printf", text: `{{printf "%0999999999d" 1}}`, wantErr: true},
+		{name: "unknown partial", text: `{{template "audience" .}}`, wantErr: true},
+	}
+
+	for _, tt := range testTable {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := set.ParseUser("compressor", tt.text)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestTemplate_RenderTooLong(t *testing.T) {
+	set, err := NewSet(nil)
+	require.NoError(t, err)
+
+	_, err = set.Parse("compressor", `{{range 1000000}}xxxxxxxxxx{{end}}`)
+	require.ErrorIs(t, err, ErrTooLong)
+}
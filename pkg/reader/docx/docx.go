@@ -3,23 +3,26 @@ package docx
 import (
 	"bytes"
 	"strings"
+	"todoai/pkg/reader"
 
 	"baliance.com/gooxml/document"
 )
 
-func ReadDocx(fileBytes []byte) (string, error) {
+// ReadDocx extracts the text of all paragraphs. A docx file has no fixed pages,
+// so the document carries none.
+func ReadDocx(fileBytes []byte) (reader.Document, error) {
 	doc, err := document.Read(bytes.NewReader(fileBytes), int64(len(fileBytes)))
 	if err != nil {
-		return "", err
+		return reader.Document{}, err
 	}
 	var builder strings.Builder
 	for _, para := range doc.Paragraphs() {
 		for _, run := range para.Runs() {
 			_, err := builder.WriteString(run.Text())
 			if err != nil {
-				return "", err
+				return reader.Document{}, err
 			}
 		}
 	}
-	return builder.String(), nil
+	return reader.Document{Text: builder.String()}, nil
 }
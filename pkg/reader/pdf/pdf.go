@@ -3,30 +3,34 @@ package pdf
 import (
 	"bytes"
 	"strings"
+	"todoai/pkg/reader"
 
 	"github.com/ledongthuc/pdf"
 )
 
-func ReadPDF(fileBytes []byte) (string, error) {
+// ReadPDF extracts the text of every page and records where each page starts in it.
+func ReadPDF(fileBytes []byte) (reader.Document, error) {
 	r, err := pdf.NewReader(bytes.NewReader(fileBytes), int64(len(fileBytes)))
 
 	if err != nil {
-		return "", err
+		return reader.Document{}, err
 	}
 
 	totalPage := r.NumPage()
+	pages := make([]int, 0, totalPage)
 	var textBuilder strings.Builder
 	for pageIndex := 1; pageIndex <= totalPage; pageIndex++ {
+		pages = append(pages, textBuilder.Len())
 		page := r.Page(pageIndex)
 		content := page.Content()
 
 		for _, text := range content.Text {
 			_, err := textBuilder.WriteString(text.S)
 			if err != nil {
-				return "", err
+				return reader.Document{}, err
 			}
 		}
 
 	}
-	return textBuilder.String(), nil
+	return reader.Document{Text: textBuilder.String(), Pages: pages}, nil
 }
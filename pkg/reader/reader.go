@@ -0,0 +1,17 @@
+package reader
+
+import "sort"
+
+// Document is the text extracted from a file together with the positions of its pages.
+type Document struct {
+	Text string
+	// Pages holds the byte offset in Text at which every page starts, in order.
+	// It is empty for formats without pages.
+	Pages []int
+}
+
+// Page returns the 1-based number of the page holding the byte at offset,
+// or zero when the document has no pages.
+func (d Document) Page(offset int) int {
+	return sort.Search(len(d.Pages), func(i int) bool { return d.Pages[i] > offset })
+}
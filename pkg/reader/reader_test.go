@@ -0,0 +1,30 @@
+package reader
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocument_Page(t *testing.T) {
+	doc := Document{Text: "first page second page third", Pages: []int{0, 11, 23}}
+
+	testTable := []struct {
+		name     string
+		offset   int
+		expected int
+	}{
+		{name: "start of the first page", offset: 0, expected: 1},
+		{name: "end of the first page", offset: 10, expected: 1},
+		{name: "start of the second page", offset: 11, expected: 2},
+		{name: "last page", offset: 27, expected: 3},
+	}
+
+	for _, tt := range testTable {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, doc.Page(tt.offset))
+		})
+	}
+
+	require.Zero(t, Document{Text: "no pages"}.Page(3))
+}
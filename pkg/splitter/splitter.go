@@ -0,0 +1,360 @@
+package splitter
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Chunk is a piece of the source text. Start and End are byte offsets into the source,
+// so Text == source[Start:End].
+type Chunk struct {
+	Text  string
+	Start int
+	End   int
+}
+
+// SizeFunc measures a piece of text, for example in words or model tokens.
+type SizeFunc func(text string) int
+
+type Options struct {
+	// ChunkSize is the maximum size of a chunk as measured by Size.
+	ChunkSize int
+	// Overlap is how much of the end of a chunk is repeated at the start of the next one.
+	// Only whole sentences are repeated.
+	Overlap int
+	// Language selects the abbreviation list ("ru", "en", "es"). Empty, or a language without
+	// a list, means all of them.
+	Language string
+	// Size measures text. Defaults to Words.
+	Size SizeFunc
+}
+
+// Words counts whitespace-separated words.
+func Words(text string) int {
+	return len(strings.Fields(text))
+}
+
+// EstimateTokens is a rough token count for when the model tokenizer is not available.
+func EstimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + 3) / 4
+}
+
+type sentence struct {
+	start, end int
+	size       int
+	paragraph  bool // the sentence starts a paragraph
+}
+
+// Split cuts text into chunks of at most opts.ChunkSize. Chunks end on sentence boundaries and,
+// when a paragraph boundary falls into the last quarter of a chunk, on paragraph boundaries.
+// A sentence longer than ChunkSize is cut between words.
+func Split(text string, opts Options) []Chunk {
+	if opts.Size == nil {
+		opts.Size = Words
+	}
+	if opts.ChunkSize <= 0 {
+		opts.ChunkSize = 1
+	}
+
+	var chunks []Chunk
+	var current []sentence
+	size := 0
+	fresh := false // current holds sentences that are not part of any chunk yet
+	emit := func() {
+		start, end := current[0].start, current[len(current)-1].end
+		chunks = append(chunks, Chunk{Text: text[start:end], Start: start, End: end})
+		current, size = overlap(current, opts.Overlap)
+		fresh = false
+	}
+
+	for _, sent := range segment(text, opts) {
+		full := size+sent.size > opts.ChunkSize
+		paragraphBreak := sent.paragraph && size*4 >= opts.ChunkSize*3
+		if fresh && (full || paragraphBreak) {
+			emit()
+		}
+		// The overlap never pushes a sentence out of the chunk.
+		for len(current) > 0 && size+sent.size > opts.ChunkSize {
+			size -= current[0].size
+			current = current[1:]
+		}
+		current = append(current, sent)
+		size += sent.size
+		fresh = true
+	}
+	if fresh {
+		emit()
+	}
+
+	return chunks
+}
+
+// Sentences cuts text into sentences. language selects the abbreviation list as in Options.
+func Sentences(text, language string) []Chunk {
+	var sentences []Chunk
+	for _, p := range paragraphs(text) {
+		for _, span := range sentenceSpans(text, p[0], p[1], language) {
+			sentences = append(sentences, Chunk{Text: text[span[0]:span[1]], Start: span[0], End: span[1]})
+		}
+	}
+	return sentences
+}
+
+// overlap returns the trailing sentences of a finished chunk that fit into limit.
+// The first sentence of the chunk is never repeated.
+func overlap(sentences []sentence, limit int) ([]sentence, int) {
+	size := 0
+	i := len(sentences)
+	for i > 1 && size+sentences[i-1].size <= limit {
+		size += sentences[i-1].size
+		i--
+	}
+	return append([]sentence(nil), sentences[i:]...), size
+}
+
+// segment cuts text into sentences, splitting any sentence longer than ChunkSize between words.
+func segment(text string, opts Options) []sentence {
+	var sentences []sentence
+	for _, p := range paragraphs(text) {
+		first := true
+		for _, span := range sentenceSpans(text, p[0], p[1], opts.Language) {
+			for _, piece := range splitLong(text, span[0], span[1], opts) {
+				piece.paragraph = first
+				first = false
+				sentences = append(sentences, piece)
+			}
+		}
+	}
+	return sentences
+}
+
+// paragraphs returns the byte ranges of paragraphs separated by blank lines.
+func paragraphs(text string) [][2]int {
+	var result [][2]int
+	start := -1
+	blank := true
+	for i := 0; i < len(text); i++ {
+		switch {
+		case text[i] == '\n':
+			if blank && start >= 0 {
+				result = append(result, [2]int{start, trimRight(text, start, i)})
+				start = -1
+			}
+			blank = true
+		case !isSpace(text[i]):
+			blank = false
+			if start < 0 {
+				start = i
+			}
+		}
+	}
+	if start >= 0 {
+		result = append(result, [2]int{start, trimRight(text, start, len(text))})
+	}
+	return result
+}
+
+// sentenceSpans returns the byte ranges of sentences in text[start:end].
+func sentenceSpans(text string, start, end int, lang string) [][2]int {
+	var spans [][2]int
+	sentStart := start
+	for i := start; i < end; {
+		r, width := utf8.DecodeRuneInString(text[i:])
+
+		// A list item, or a line that follows a terminated line, starts a new sentence.
+		if r == '\n' {
+			next := skipSpace(text, i, end)
+			if next < end && (isListMarker(text[next:end]) || endsSentence(text[sentStart:i])) {
+				spans = appendSpan(spans, text, sentStart, i)
+				sentStart = next
+			}
+			i += width
+			continue
+		}
+
+		if !isTerminal(r) {
+			i += width
+			continue
+		}
+
+		j := i + width
+		for j < end {
+			r2, w2 := utf8.DecodeRuneInString(text[j:])
+			if !isTerminal(r2) && !isClosing(r2) {
+				break
+			}
+			j += w2
+		}
+		if j < end && !isSpace(text[j]) {
+			i = j
+			continue
+		}
+
+		next := skipSpace(text, j, end)
+		if next < end {
+			r3, _ := utf8.DecodeRuneInString(text[next:])
+			if !startsSentence(r3) || (r == '.' && isAbbreviation(lastWord(text, sentStart, i), lang)) {
+				i = j
+				continue
+			}
+		}
+
+		spans = appendSpan(spans, text, sentStart, j)
+		sentStart = next
+		i = next
+	}
+	return appendSpan(spans, text, sentStart, end)
+}
+
+func appendSpan(spans [][2]int, text string, start, end int) [][2]int {
+	end = trimRight(text, start, end)
+	if end > start {
+		spans = append(spans, [2]int{start, end})
+	}
+	return spans
+}
+
+// splitLong cuts a sentence that does not fit into a chunk into pieces of whole words.
+func splitLong(text string, start, end int, opts Options) []sentence {
+	size := opts.Size(text[start:end])
+	if size <= opts.ChunkSize {
+		return []sentence{{start: start, end: end, size: size}}
+	}
+
+	var words [][2]int
+	for i := start; i < end; {
+		i = skipSpace(text, i, end)
+		j := i
+		for j < end && !isSpace(text[j]) {
+			j++
+		}
+		if j > i {
+			words = append(words, [2]int{i, j})
+		}
+		i = j
+	}
+
+	perPiece := max(len(words)*opts.ChunkSize/size, 1)
+	var pieces []sentence
+	for i := 0; i < len(words); i += perPiece {
+		j := min(i+perPiece, len(words))
+		s, e := words[i][0], words[j-1][1]
+		pieces = append(pieces, sentence{start: s, end: e, size: opts.Size(text[s:e])})
+	}
+	return pieces
+}
+
+func lastWord(text string, start, end int) string {
+	i := end
+	for i > start && !isSpace(text[i-1]) {
+		i--
+	}
+	return strings.TrimLeftFunc(text[i:end], func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+}
+
+func isSpace(b byte) bool {
+	switch b {
+	case ' ', '\t', '\n', '\r', '\v', '\f':
+		return true
+	}
+	return false
+}
+
+func skipSpace(text string, i, end int) int {
+	for i < end && isSpace(text[i]) {
+		i++
+	}
+	return i
+}
+
+func trimRight(text string, start, end int) int {
+	for end > start && isSpace(text[end-1]) {
+		end--
+	}
+	return end
+}
+
+func isTerminal(r rune) bool {
+	switch r {
+	case '.', '!', '?', '…':
+		return true
+	}
+	return false
+}
+
+func isClosing(r rune) bool {
+	switch r {
+	case '"', '\'', ')', ']', '»', '”', '’':
+		return true
+	}
+	return false
+}
+
+func startsSentence(r rune) bool {
+	if unicode.IsUpper(r) || unicode.IsDigit(r) {
+		return true
+	}
+	switch r {
+	case '"', '\'', '(', '[', '«', '“', '‘', '¿', '¡', '-', '—', '–':
+		return true
+	}
+	return false
+}
+
+func endsSentence(text string) bool {
+	r, _ := utf8.DecodeLastRuneInString(strings.TrimRightFunc(text, func(r rune) bool {
+		return unicode.IsSpace(r) || isClosing(r)
+	}))
+	return isTerminal(r) || r == ':' || r == ';'
+}
+
+func isListMarker(line string) bool {
+	r, w := utf8.DecodeRuneInString(line)
+	switch r {
+	case '-', '*', '•', '—', '–':
+		return w < len(line) && line[w] == ' '
+	}
+	i := 0
+	for i < len(line) && line[i] >= '0' && line[i] <= '9' {
+		i++
+	}
+	return i > 0 && i+1 < len(line) && (line[i] == '.' || line[i] == ')') && line[i+1] == ' '
+}
+
+// isAbbreviation reports whether word, the last word before a period, is an abbreviation or
+// an initial, in which case the period does not end the sentence.
+func isAbbreviation(word string, lang string) bool {
+	if utf8.RuneCountInString(word) == 1 {
+		r, _ := utf8.DecodeRuneInString(word)
+		return unicode.IsUpper(r)
+	}
+
+	word = strings.ToLower(word)
+	if list, ok := abbreviations[lang]; ok {
+		_, ok := list[word]
+		return ok
+	}
+	for _, list := range abbreviations {
+		if _, ok := list[word]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+var abbreviations = map[string]map[string]struct{}{
+	"en": set("mr", "mrs", "ms", "dr", "prof", "sr", "jr", "st", "vs", "e.g", "i.e", "inc", "ltd", "co", "corp", "no", "art", "sec", "para", "fig", "approx", "jan", "feb", "mar", "apr", "jun", "jul", "aug", "sep", "sept", "oct", "nov", "dec"),
+	"ru": set("т", "т.е", "т.д", "т.п", "т.к", "др", "пр", "см", "ст", "п", "пп", "ч", "г", "гг", "в", "вв", "им", "ул", "д", "кв", "руб", "коп", "тыс", "млн", "млрд", "рис", "стр", "н.э", "напр", "проф", "акад", "и.о"),
+	"es": set("sr", "sra", "srta", "dr", "dra", "lic", "ing", "arq", "av", "avda", "art", "núm", "pág", "págs", "ej", "p.ej", "ee.uu", "ud", "uds", "vd", "vds", "cía", "s.a", "aprox", "dto"),
+}
+
+func set(words ...string) map[string]struct{} {
+	m := make(map[string]struct{}, len(words))
+	for _, w := range words {
+		m[w] = struct{}{}
+	}
+	return m
+}
@@ -0,0 +1,123 @@
+package splitter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func chunkTexts(chunks []Chunk) []string {
+	var texts []string
+	for _, c := range chunks {
+		texts = append(texts, c.Text)
+	}
+	return texts
+}
+
+func TestSplit(t *testing.T) {
+	testTable := []struct {
+		name     string
+		text     string
+		opts     Options
+		expected []string
+	}{
+		{
+			name:     "empty text",
+			text:     "  \n\n ",
+			opts:     Options{ChunkSize: 10},
+			expected: nil,
+		},
+		{
+			name:     "fits into one chunk",
+			text:     "First sentence. Second sentence.",
+			opts:     Options{ChunkSize: 10},
+			expected: []string{"First sentence. Second sentence."},
+		},
+		{
+			name:     "cuts on sentence boundaries",
+			text:     "One two three. Four five six. Seven eight.",
+			opts:     Options{ChunkSize: 6},
+			expected: []string{"One two three. Four five six.", "Seven eight."},
+		},
+		{
+			name:     "keeps abbreviations and initials",
+			text:     "Dr. Smith met J. R. Tolkien. They talked.",
+			opts:     Options{ChunkSize: 6, Language: "en"},
+			expected: []string{"Dr. Smith met J. R. Tolkien.", "They talked."},
+		},
+		{
+			name:     "russian abbreviations",
+			text:     "В 2020 г. закон, т.е. акт, вступил в силу. Затем его отменили.",
+			opts:     Options{ChunkSize: 9, Language: "ru"},
+			expected: []string{"В 2020 г. закон, т.е. акт, вступил в силу.", "Затем его отменили."},
+		},
+		{
+			name:     "spanish punctuation",
+			text:     "¿Dónde está el Sr. Gómez? ¡Aquí está! Muy bien.",
+			opts:     Options{ChunkSize: 7, Language: "es"},
+			expected: []string{"¿Dónde está el Sr. Gómez? ¡Aquí está!", "Muy bien."},
+		},
+		{
+			name:     "language without a list uses all of them",
+			text:     "Dr. Müller kam um 8 Uhr. Er ging.",
+			opts:     Options{ChunkSize: 6, Language: "de"},
+			expected: []string{"Dr. Müller kam um 8 Uhr.", "Er ging."},
+		},
+		{
+			name:     "prefers paragraph boundaries",
+			text:     "One two three four. Five six seven.\n\nEight nine. Ten.",
+			opts:     Options{ChunkSize: 9},
+			expected: []string{"One two three four. Five six seven.", "Eight nine. Ten."},
+		},
+		{
+			name:     "repeats trailing sentences as overlap",
+			text:     "One two. Three four. Five six. Seven eight.",
+			opts:     Options{ChunkSize: 4, Overlap: 2},
+			expected: []string{"One two. Three four.", "Three four. Five six.", "Five six. Seven eight."},
+		},
+		{
+			name:     "cuts long sentences between words",
+			text:     "one two three four five six seven",
+			opts:     Options{ChunkSize: 3},
+			expected: []string{"one two three", "four five six", "seven"},
+		},
+		{
+			name:     "list items are sentences",
+			text:     "Steps:\n- open the file\n- read it",
+			opts:     Options{ChunkSize: 5},
+			expected: []string{"Steps:\n- open the file", "- read it"},
+		},
+	}
+
+	for _, tt := range testTable {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks := Split(tt.text, tt.opts)
+			require.Equal(t, tt.expected, chunkTexts(chunks))
+
+			for _, c := range chunks {
+				require.Equal(t, c.Text, tt.text[c.Start:c.End])
+			}
+		})
+	}
+}
+
+func TestSplit_CustomSize(t *testing.T) {
+	text := strings.Repeat("Hello world. ", 10)
+	chunks := Split(text, Options{ChunkSize: 30, Size: func(s string) int { return len(s) }})
+
+	require.NotEmpty(t, chunks)
+	for _, c := range chunks {
+		require.LessOrEqual(t, len(c.Text), 30)
+	}
+}
+
+func TestSentences(t *testing.T) {
+	text := "Dr. Smith met J. R. Tolkien. They talked.\n\nThen they left."
+	sentences := Sentences(text, "en")
+
+	require.Equal(t, []string{"Dr. Smith met J. R. Tolkien.", "They talked.", "Then they left."}, chunkTexts(sentences))
+	for _, sentence := range sentences {
+		require.Equal(t, sentence.Text, text[sentence.Start:sentence.End])
+	}
+}
@@ -30,5 +30,111 @@ CREATE TABLE lists (
     title VARCHAR(64) NOT NULL, 
     body TEXT NOT NULL, 
     user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP, 
-); 
\ No newline at end of file
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    version INTEGER NOT NULL DEFAULT 1,
+    -- The russian configuration stems Cyrillic words as Russian and Latin words as English.
+    search TSVECTOR GENERATED ALWAYS AS (
+        setweight(to_tsvector('russian', title), 'A') || setweight(to_tsvector('russian', body), 'B')
+    ) STORED
+);
+
+CREATE INDEX lists_user_created_idx ON lists (user_id, created_at, id);
+CREATE INDEX lists_user_updated_idx ON lists (user_id, updated_at, id);
+CREATE INDEX lists_user_title_idx ON lists (user_id, title, id);
+CREATE INDEX lists_search_idx ON lists USING GIN (search);
+
+CREATE TABLE list_items (
+    id SERIAL PRIMARY KEY,
+    list_id INTEGER NOT NULL REFERENCES lists(id) ON DELETE CASCADE,
+    text TEXT NOT NULL,
+    due DATE,
+    owner VARCHAR(128) NOT NULL DEFAULT '',
+    priority VARCHAR(8) NOT NULL DEFAULT '',
+    done BOOLEAN NOT NULL DEFAULT FALSE,
+    position INTEGER NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX list_items_list_position_idx ON list_items (list_id, position);
+
+CREATE TABLE modes (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(64) NOT NULL,
+    compressor_prompt TEXT NOT NULL,
+    formatter_prompt TEXT NOT NULL,
+    compress_temp REAL,
+    format_temp REAL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, name)
+);
+
+CREATE TABLE summaries (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    filename TEXT NOT NULL DEFAULT '',
+    mode VARCHAR(64) NOT NULL,
+    text_hash CHAR(64) NOT NULL,
+    result TEXT NOT NULL,
+    format VARCHAR(16) NOT NULL DEFAULT '',
+    structured JSONB,
+    outline JSONB,
+    citations JSONB,
+    source_language VARCHAR(8) NOT NULL DEFAULT '',
+    target_language VARCHAR(8) NOT NULL DEFAULT '',
+    model VARCHAR(128) NOT NULL,
+    duration_ms BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    search TSVECTOR GENERATED ALWAYS AS (
+        setweight(to_tsvector('russian', filename), 'A') || setweight(to_tsvector('russian', result), 'B')
+    ) STORED
+);
+
+CREATE INDEX summaries_user_created_idx ON summaries (user_id, created_at DESC);
+CREATE INDEX summaries_search_idx ON summaries USING GIN (search);
+
+CREATE TABLE jobs (
+    id UUID PRIMARY KEY,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    mode VARCHAR(64) NOT NULL,
+    options JSONB NOT NULL DEFAULT '{}',
+    filename TEXT NOT NULL DEFAULT '',
+    source BYTEA NOT NULL,
+    chunks_done INTEGER NOT NULL DEFAULT 0,
+    chunks_total INTEGER NOT NULL DEFAULT 0,
+    result TEXT,
+    structured JSONB,
+    outline JSONB,
+    citations JSONB,
+    source_language VARCHAR(8) NOT NULL DEFAULT '',
+    summary_id INTEGER REFERENCES summaries(id) ON DELETE SET NULL,
+    cache_hits INTEGER,
+    cache_misses INTEGER,
+    error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX jobs_status_idx ON jobs (status); 
+
+CREATE TABLE ai_usage (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    prompt_tokens INTEGER NOT NULL,
+    output_tokens INTEGER NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX ai_usage_user_created_idx ON ai_usage (user_id, created_at);
+
+CREATE INDEX ai_usage_anonymous_created_idx ON ai_usage (created_at) WHERE user_id IS NULL;
+
+CREATE TABLE ai_cache (
+    key CHAR(64) PRIMARY KEY,
+    value BYTEA NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX ai_cache_expires_idx ON ai_cache (expires_at);